@@ -10,9 +10,16 @@ Simple command line interface for OpsKit providing:
 
 import os
 import sys
+import json
+import logging
+import time
+import fcntl
+import contextlib
+import shlex
 import subprocess
 import shutil
-from typing import Dict, List, Optional
+from html import escape as html_escape
+from typing import Dict, List, Optional, Tuple
 from pathlib import Path
 
 try:
@@ -21,15 +28,20 @@ try:
     from rich.panel import Panel
     from rich.prompt import Prompt, Confirm
     from rich.align import Align
+    from rich.markup import escape
     rich_available = True
 except ImportError:
     rich_available = False
 
 from .env import env, get_tool_temp_dir, load_tool_env, get_config_summary, is_first_run, initialize_env_file
 from .platform_utils import PlatformUtils
-from .dependency_manager import DependencyManager
+from .dependency_manager import DependencyManager, TOOL_TYPES
+from .history import record_tool_run, get_last_run, get_recent_tools, get_seen_tools, add_seen_tools
+from .config_loader import load_yaml_file, describe_yaml_error, ConfigError
 import yaml
 
+logger = logging.getLogger(__name__)
+
 
 class OpsKitCLI:
     """Simple command line interface for OpsKit"""
@@ -45,6 +57,8 @@ class OpsKitCLI:
         
         # Tool cache
         self._tool_cache = None
+        self._tools_config = None
+        self._visibility_cache = {}
         
         # Initialize managers
         self.platform_utils = PlatformUtils()
@@ -122,6 +136,50 @@ class OpsKitCLI:
             self._print(separator)
             self._print("")  # Add spacing after header
     
+    def _tool_info_fields(self, tool: Dict) -> List[Tuple[str, str]]:
+        """(label, value) pairs describing a tool, skipping unset optional fields"""
+        fields = [
+            ("Name", tool['name']),
+            ("Version", tool.get('version', '1.0.0')),
+            ("Category", tool['category']),
+            ("Type", tool['type']),
+            ("Description", tool['description']),
+            ("Path", str(Path(tool['path']) / tool['main_file'])),
+        ]
+        optional = [
+            ("Aliases", ', '.join(tool.get('aliases') or [])),
+            ("Keywords", ', '.join(tool.get('keywords') or [])),
+            ("Tags", ', '.join(tool.get('tags') or [])),
+            ("Dependencies", ', '.join(tool.get('dependencies') or [])),
+            ("Requires tools", ', '.join(tool.get('requires_tools') or [])),
+            ("Environment", ', '.join(tool.get('env') or {})),
+            ("Docs", tool.get('docs') or ''),
+            ("Health command", tool.get('health_command') or ''),
+            ("Timeout", f"{tool['timeout']}s" if tool.get('timeout') else ''),
+            ("Cooldown", f"{tool['cooldown']}s" if tool.get('cooldown') else ''),
+            ("Singleton", 'yes' if tool.get('singleton') else ''),
+            ("Requires TTY", 'yes' if tool.get('requires_tty') else ''),
+            ("Disabled", (tool.get('disabled_reason') or 'yes') if tool.get('disabled') else ''),
+            ("Dangerous", (tool.get('confirm_prompt') or 'yes') if tool.get('dangerous') else ''),
+        ]
+        return fields + [(label, str(value)) for label, value in optional if value]
+    
+    def _print_tool_info(self, tool: Dict) -> None:
+        """Print every known detail of a tool"""
+        fields = self._tool_info_fields(tool)
+        
+        if self.console and rich_available:
+            grid = Table.grid(padding=(0, 2))
+            grid.add_column(style="yellow", no_wrap=True)
+            grid.add_column()
+            for label, value in fields:
+                grid.add_row(label, escape(value))
+            self.console.print(Panel(grid, title=f"🔧 {tool['name']}", title_align="left", border_style="blue"))
+        else:
+            for label, value in fields:
+                print(f"{label + ':':<16} {value}")
+            print()
+    
     def discover_tools(self, force_refresh: bool = False) -> Dict[str, List[Dict[str, str]]]:
         """
         Discover all available tools
@@ -132,6 +190,9 @@ class OpsKitCLI:
         if self._tool_cache is not None and not force_refresh:
             return self._tool_cache
         
+        if force_refresh:
+            self._tools_config = None
+        
         tools = {}
         
         if not self.tools_dir.exists():
@@ -161,6 +222,101 @@ class OpsKitCLI:
         self._tool_cache = tools
         return tools
     
+    def _acquire_tool_lock(self, tool_name: str):
+        """
+        Take the exclusive run lock of a singleton tool
+        
+        The OS drops the lock when the holding process exits, so a crashed
+        run never leaves a stale lock behind.
+        
+        Returns:
+            (lock_file, None) on success, (None, holder_pid) if another process holds it
+        """
+        lock_dir = Path(env.cache_dir) / 'locks'
+        lock_dir.mkdir(parents=True, exist_ok=True)
+        lock_file = open(lock_dir / f'{tool_name}.lock', 'a+')
+        
+        try:
+            fcntl.flock(lock_file, fcntl.LOCK_EX | fcntl.LOCK_NB)
+        except OSError:
+            lock_file.seek(0)
+            holder_pid = lock_file.read().strip() or 'unknown'
+            lock_file.close()
+            return None, holder_pid
+        
+        lock_file.seek(0)
+        lock_file.truncate()
+        lock_file.write(str(os.getpid()))
+        lock_file.flush()
+        return lock_file, None
+    
+    def _confirm_dangerous(self, tool: Dict) -> bool:
+        """Ask the user to type 'yes' before running a tool marked dangerous"""
+        name = tool['name']
+        if not sys.stdin.isatty():
+            self._print(f"Tool '{name}' is marked dangerous and needs confirmation; "
+                        f"pass --yes to run it without a terminal", "red")
+            return False
+        
+        self._print(tool.get('confirm_prompt') or f"⚠️  Tool '{name}' is marked dangerous.", "bold red")
+        try:
+            answer = self._input(f"Type 'yes' to run {name}")
+        except (EOFError, KeyboardInterrupt):
+            answer = ''
+        if answer.strip().lower() != 'yes':
+            self._print("Cancelled.", "yellow")
+            return False
+        return True
+    
+    @staticmethod
+    def _has_tty() -> bool:
+        """Check whether stdin and stdout are both attached to a terminal"""
+        try:
+            return sys.stdin.isatty() and sys.stdout.isatty()
+        except (AttributeError, ValueError):
+            return False
+    
+    @staticmethod
+    def _parse_duration(value) -> int:
+        """Parse a duration in seconds: a number, or a string such as '30s', '5m', '1h', '1d'"""
+        if isinstance(value, (int, float)):
+            return int(value)
+        
+        units = {'s': 1, 'm': 60, 'h': 3600, 'd': 86400}
+        value = str(value).strip().lower()
+        try:
+            if value and value[-1] in units:
+                return int(float(value[:-1]) * units[value[-1]])
+            return int(float(value or 0))
+        except ValueError:
+            # Treated as unset; _load_tools_config warns about it with the field's location
+            return 0
+    
+    def _load_tools_config(self) -> Dict:
+        """Load config/tools.yaml once per CLI instance"""
+        if self._tools_config is not None:
+            return self._tools_config
+        
+        self._tools_config = {}
+        tools_yaml_path = self.opskit_root / 'config' / 'tools.yaml'
+        if tools_yaml_path.exists():
+            try:
+                self._tools_config = load_yaml_file(tools_yaml_path, 'config/tools.yaml')
+            except ConfigError as e:
+                # Tools stay usable with default metadata, but say why it is missing
+                logger.warning(f"⚠️  Ignoring tools config: {e}")
+        
+        # Point out mistakes such as a misspelt field right away, without blocking
+        # the run; advisory findings are left to 'opskit validate' and the log
+        if self._tools_config:
+            errors, warnings = self._validate_tools_config(self._tools_config)
+            for problem in errors:
+                logger.warning(f"⚠️  config/tools.yaml: {problem} (see 'opskit validate')")
+            for problem in warnings:
+                logger.info(f"config/tools.yaml: {problem}")
+        
+        return self._tools_config
+    
     def _parse_tool_info(self, tool_dir: Path) -> Optional[Dict[str, str]]:
         """Parse tool information from directory"""
         try:
@@ -169,7 +325,7 @@ class OpsKitCLI:
             
             # Look for main executable
             main_file = None
-            for candidate in ['main.py', 'main.sh', f'{tool_name}.py', f'{tool_name}.sh']:
+            for candidate in [f'{stem}{ext}' for stem in ('main', tool_name) for ext in TOOL_TYPES]:
                 candidate_path = tool_dir / candidate
                 if candidate_path.exists():
                     main_file = candidate
@@ -181,43 +337,105 @@ class OpsKitCLI:
             # Get version and description from tools.yaml
             version = "1.0.0"  # default version
             description = "No description available"
+            keywords = []  # default no keywords
+            tags = []  # default no tags
+            aliases = []  # default no alternative names
             dependencies = []  # default no dependencies
+            health_command = None  # default no health check
+            docs = None  # default to the tool's CLAUDE.md
+            requires_tools = []  # default no required tools
+            cooldown = 0  # default no cooldown
+            requires_tty = False  # default runs without a terminal
+            singleton = False  # default allows concurrent runs
+            timeout = 0  # default no timeout
+            visible_if = []  # default always listed
+            input_json = 'env'  # default JSON input via OPSKIT_INPUT_JSON
+            disabled = False  # default runnable
+            disabled_reason = None
+            tool_env = {}  # default no extra environment
+            dangerous = False  # default runs without confirmation
+            confirm_prompt = None
             
-            # Load tools.yaml for metadata
-            tools_yaml_path = self.opskit_root / 'config' / 'tools.yaml'
-            if tools_yaml_path.exists():
-                try:
-                    with open(tools_yaml_path, 'r', encoding='utf-8') as f:
-                        tools_config = yaml.safe_load(f)
-                    
-                    if tools_config and 'tools' in tools_config:
-                        tool_info_config = tools_config['tools'].get(category, {}).get(tool_name, {})
-                        if tool_info_config:
-                            version = tool_info_config.get('version', version)
-                            description = tool_info_config.get('description', description)
-                            # Extract dependencies from tools.yaml
-                            dependencies = tool_info_config.get('dependencies', [])
-                except Exception:
-                    pass
+            # Metadata from tools.yaml
+            tools_config = self._load_tools_config()
+            tool_info_config = (tools_config.get('tools') or {}).get(category, {}).get(tool_name, {})
+            if tool_info_config:
+                version = tool_info_config.get('version', version)
+                description = tool_info_config.get('description', description)
+                keywords = [str(keyword) for keyword in tool_info_config.get('keywords') or []]
+                tags = [str(tag) for tag in tool_info_config.get('tags') or []]
+                aliases = [str(alias) for alias in tool_info_config.get('aliases') or []]
+                # Extract dependencies from tools.yaml
+                dependencies = tool_info_config.get('dependencies', [])
+                health_command = tool_info_config.get('health_command')
+                docs = tool_info_config.get('docs')
+                requires_tools = tool_info_config.get('requires_tools', [])
+                cooldown = self._parse_duration(tool_info_config.get('cooldown', 0))
+                requires_tty = bool(tool_info_config.get('requires_tty', False))
+                singleton = bool(tool_info_config.get('singleton', False))
+                timeout = self._parse_duration(tool_info_config.get('timeout', 0))
+                visible_if = tool_info_config.get('visible_if') or []
+                if isinstance(visible_if, str):
+                    visible_if = [visible_if]
+                input_json = tool_info_config.get('input_json', input_json)
+                disabled = bool(tool_info_config.get('disabled', False))
+                disabled_reason = tool_info_config.get('disabled_reason')
+                tool_env = {str(key): str(value) for key, value in (tool_info_config.get('env') or {}).items()}
+                confirm_prompt = tool_info_config.get('confirm_prompt')
+                dangerous = bool(tool_info_config.get('dangerous', False)) or bool(confirm_prompt)
+            
+            # Determine tool type
+            tool_type = TOOL_TYPES[Path(main_file).suffix]
+            
+            # Defaults shared by every tool of this type; tool-level settings win
+            type_defaults = (tools_config.get('type_defaults') or {}).get(tool_type) or {}
+            if 'timeout' not in (tool_info_config or {}):
+                timeout = self._parse_duration(type_defaults.get('timeout', 0))
+            default_args = [str(arg) for arg in type_defaults.get('args') or []]
+            default_env = {str(key): str(value) for key, value in (type_defaults.get('env') or {}).items()}
+            interpreters = [str(command) for command in type_defaults.get('interpreters') or []]
+            
+            # Resolve documentation: URL as-is, relative paths against the tool directory
+            if docs and '://' not in docs and not os.path.isabs(docs):
+                docs = str(tool_dir / docs)
+            elif not docs and (tool_dir / 'CLAUDE.md').exists():
+                docs = str(tool_dir / 'CLAUDE.md')
             
             # Check for requirements and env file
             has_python_deps = (tool_dir / 'requirements.txt').exists()
             has_env_file = (tool_dir / '.env').exists()
             
-            # Determine tool type
-            tool_type = 'python' if main_file.endswith('.py') else 'shell'
-            
             return {
                 'name': tool_name,
                 'path': str(tool_dir),
                 'main_file': main_file,
                 'description': description,
+                'keywords': keywords,
+                'tags': tags,
+                'aliases': aliases,
                 'version': version,
                 'type': tool_type,
                 'has_python_deps': has_python_deps,
                 'has_env_file': has_env_file,
                 'category': category,
-                'dependencies': dependencies
+                'dependencies': dependencies,
+                'health_command': health_command,
+                'docs': docs,
+                'requires_tools': requires_tools,
+                'cooldown': cooldown,
+                'requires_tty': requires_tty,
+                'singleton': singleton,
+                'timeout': timeout,
+                'default_args': default_args,
+                'default_env': default_env,
+                'env': tool_env,
+                'interpreters': interpreters,
+                'visible_if': visible_if,
+                'input_json': input_json,
+                'disabled': disabled,
+                'disabled_reason': disabled_reason,
+                'dangerous': dangerous,
+                'confirm_prompt': confirm_prompt
             }
         
         except Exception:
@@ -234,6 +452,14 @@ class OpsKitCLI:
                 self._print("Setup cancelled. You can configure later with: opskit config", "yellow")
                 return
 
+        # Tools run lately come first, so the usual ones need no searching
+        recent = [tool for tool in (self._find_tool(name) for name in get_recent_tools(env.recent_tools)) if tool]
+        if recent:
+            self._print("Recently used:", "bold blue")
+            for tool in recent:
+                self._print(f"  {self._display_name(tool)} - {tool['description']}")
+            self._print("")
+        
         # Show available tools
         self._print("Available tools:", "bold blue")
         self.list_tools()
@@ -242,19 +468,92 @@ class OpsKitCLI:
         self._print("Use 'opskit list' to see this list again", "yellow")
         self._print("Use 'opskit config' for configuration", "yellow")
     
-    def list_tools(self, category: Optional[str] = None) -> None:
-        """List all tools or tools in a specific category"""
-        tools = self.discover_tools()
+    def _missing_visibility_commands(self, tool: Dict) -> List[str]:
+        """Commands named by a tool's visible_if that are not on PATH (checked once per run)"""
+        if tool['name'] not in self._visibility_cache:
+            self._visibility_cache[tool['name']] = [
+                command for command in tool.get('visible_if', []) if not shutil.which(command)
+            ]
+        return self._visibility_cache[tool['name']]
+    
+    def _visible_tools(self) -> Dict[str, List[Dict]]:
+        """Discovered tools without those hidden by visible_if on this host"""
+        visible = {}
+        for category, cat_tools in self.discover_tools().items():
+            shown = [tool for tool in cat_tools if not self._missing_visibility_commands(tool)]
+            if shown:
+                visible[category] = shown
+        return visible
+    
+    @staticmethod
+    def _display_name(tool: Dict) -> str:
+        """Tool name as shown in listings, marking disabled tools"""
+        return f"{tool['name']} (disabled)" if tool.get('disabled') else tool['name']
+    
+    def list_tools(self, category: Optional[str] = None, as_json: bool = False, only_new: bool = False,
+                   tags: Optional[List[str]] = None) -> int:
+        """
+        List all tools or tools in a specific category, optionally only those with every given tag
+        
+        Returns:
+            1 if --json was given an unknown category, otherwise 0
+        """
+        tools = self._visible_tools()
+        
+        # Narrow to a known category first, so a category emptied by the
+        # filters below lists nothing instead of falling back to every category
+        known_category = bool(category) and category in self.discover_tools()
+        if category and not known_category and as_json:
+            # A typo must not hand every tool to a script
+            print("[]")
+            print(f"Unknown category '{category}'", file=sys.stderr)
+            return 1
+        if known_category:
+            tools = {category: tools[category]} if category in tools else {}
+        
+        # Tags (e.g. aws, readonly) are matched case-insensitively
+        if tags:
+            wanted = {tag.lower() for tag in tags}
+            tools = {cat_name: [tool for tool in cat_tools
+                                if wanted <= {tag.lower() for tag in tool.get('tags', [])}]
+                     for cat_name, cat_tools in tools.items()}
+            tools = {cat_name: cat_tools for cat_name, cat_tools in tools.items() if cat_tools}
+        
+        if only_new:
+            seen = get_seen_tools()
+            if seen is None:
+                # The first run records every tool as the baseline to compare against
+                seen = [tool['name'] for cat_tools in self.discover_tools().values() for tool in cat_tools]
+                add_seen_tools(seen)
+                if not as_json:
+                    self._print("Recorded the current tools; later 'opskit list --new' runs "
+                                "show tools added after this", "yellow")
+                    return 0
+            seen = set(seen)
+            tools = {cat_name: [tool for tool in cat_tools if tool['name'] not in seen]
+                     for cat_name, cat_tools in tools.items()}
+            tools = {cat_name: cat_tools for cat_name, cat_tools in tools.items() if cat_tools}
+            
+            # Only tools shown now count as seen; those filtered out stay new
+            add_seen_tools([tool['name'] for cat_tools in tools.values() for tool in cat_tools])
+            if not tools and not as_json:
+                self._print("No new tools since the last 'opskit list --new'")
+                return 0
+        
+        if as_json:
+            print(json.dumps([self._tool_summary(tool) for cat_tools in tools.values() for tool in cat_tools],
+                             indent=2, ensure_ascii=False))
+            return 0
         
         if not tools:
             self._print("No tools found.")
-            return
+            return 0
         
-        if category and category in tools:
+        if known_category:
             # Show specific category
             self._print(f"Tools in category '{category}':")
             for tool in tools[category]:
-                self._print(f"  {tool['name']} - {tool['description']}")
+                self._print(f"  {self._display_name(tool)} - {tool['description']}")
         else:
             # Show all categories
             if rich_available and self.console:
@@ -269,7 +568,7 @@ class OpsKitCLI:
                         category_display = cat_name if i == 0 else ""
                         table.add_row(
                             category_display,
-                            tool['name'],
+                            self._display_name(tool),
                             tool['type'],
                             tool['description'][:60] + ('...' if len(tool['description']) > 60 else '')
                         )
@@ -279,28 +578,80 @@ class OpsKitCLI:
                 for cat_name, cat_tools in tools.items():
                     print(f"\n{cat_name}:")
                     for tool in cat_tools:
-                        print(f"  {tool['name']} ({tool['type']}) - {tool['description']}")
+                        print(f"  {self._display_name(tool)} ({tool['type']}) - {tool['description']}")
+        
+        return 0
     
-    def run_tool(self, tool_name: str, tool_args: List[str] = None) -> int:
+    def run_tool(self, tool_name: str, tool_args: List[str] = None, force: bool = False,
+                 prefix_output: bool = False, dry_run: bool = False, input_json: Optional[str] = None,
+                 yes: bool = False) -> int:
         """Run a specific tool with environment variable injection and dependency management"""
         if tool_args is None:
             tool_args = []
         
         # Find the tool
-        tools = self.discover_tools()
-        found_tool = None
-        
-        for category, cat_tools in tools.items():
-            for tool in cat_tools:
-                if tool['name'] == tool_name:
-                    found_tool = tool
-                    break
-            if found_tool:
-                break
+        found_tool = self._find_tool(tool_name)
         
         if not found_tool:
             self._print(f"Tool '{tool_name}' not found", "red")
             return 1
+        tool_name = found_tool['name']
+        
+        # Tools can be blocked centrally (e.g. during an incident); --force overrides
+        if found_tool.get('disabled'):
+            reason = found_tool.get('disabled_reason') or 'no reason given'
+            if not force:
+                self._print(f"Tool '{tool_name}' is disabled: {reason} "
+                            f"(use 'opskit run --force {tool_name}' to override)", "red")
+                return 1
+            self._print(f"⚠️  Running disabled tool '{tool_name}' ({reason})", "yellow")
+        
+        # Validate a structured input payload before anything runs
+        payload = None
+        if input_json is not None:
+            try:
+                payload = json.dumps(json.loads(input_json), ensure_ascii=False, separators=(',', ':'))
+            except ValueError as e:
+                self._print(f"❌ Invalid --input-json payload: {e}", "red")
+                return 1
+        input_via_stdin = payload is not None and found_tool.get('input_json') == 'stdin'
+        if input_via_stdin and found_tool.get('requires_tty'):
+            self._print(f"Tool '{tool_name}' is interactive and cannot read JSON input from stdin", "red")
+            return 1
+        
+        if dry_run:
+            return self._show_dry_run(found_tool, tool_args, payload)
+        
+        # Hidden tools stay runnable by name, but the user should know why it is hidden
+        missing_commands = self._missing_visibility_commands(found_tool)
+        if missing_commands:
+            self._print(f"⚠️  Tool '{tool_name}' is hidden on this host "
+                        f"(visible_if: {', '.join(missing_commands)} not found)", "yellow")
+        
+        # Refuse rapid re-runs of tools with a cooldown
+        remaining = self._cooldown_remaining(found_tool)
+        if remaining and not force:
+            self._print(f"Tool '{tool_name}' is cooling down, {remaining} seconds remaining "
+                        f"(use 'opskit run --force {tool_name}' to override)", "yellow")
+            return 1
+        
+        # Interactive tools would hang waiting for input that never comes
+        if found_tool.get('requires_tty') and not self._has_tty():
+            self._print(f"Tool '{tool_name}' is interactive and requires a terminal (TTY); "
+                        f"run it from an interactive shell", "red")
+            return 1
+        
+        # Destructive tools need an explicit 'yes'; --yes confirms up front for automation
+        if found_tool.get('dangerous') and not yes and not self._confirm_dangerous(found_tool):
+            return 1
+        
+        # Only one instance of a singleton tool may run at a time
+        lock = None
+        if found_tool.get('singleton'):
+            lock, holder_pid = self._acquire_tool_lock(tool_name)
+            if lock is None:
+                self._print(f"Tool '{tool_name}' is already running (pid {holder_pid})", "red")
+                return 1
         
         # Display comprehensive tool header
         tool_version = found_tool.get('version', '1.0.0')
@@ -313,47 +664,355 @@ class OpsKitCLI:
         
         try:
             # 1. Inject environment variables
-            tool_path = found_tool['path']
-            
-            # Create tool-specific temporary directory
-            tool_temp_dir = get_tool_temp_dir(found_tool['name'])
-            
-            # Inject environment variables with tool temp dir and base path
-            env_vars = load_tool_env(tool_path)
-            env_vars['OPSKIT_TOOL_TEMP_DIR'] = tool_temp_dir
-            env_vars['OPSKIT_BASE_PATH'] = str(self.opskit_root)
+            self._inject_tool_env(found_tool)
             
-            # Inject user's working directory (where opskit command was executed)
-            # This allows tools to know the user's actual working directory, not the tool's directory
-            env_vars['OPSKIT_WORKING_DIR'] = os.getcwd()
+            # 2. Prepare tools this tool orchestrates and expose their paths
+            for required_tool in self._resolve_required_tools(found_tool):
+                success, message = self.dependency_manager.ensure_tool_dependencies(required_tool)
+                if not success:
+                    self._print(f"❌ Required tool '{required_tool['name']}' is not ready: {message}", "red")
+                    return 1
+                env_name = self._tool_path_env_name(required_tool['name'])
+                os.environ[env_name] = str(Path(required_tool['path']) / required_tool['main_file'])
             
-            # Inject tool metadata for shell tools
-            env_vars['TOOL_NAME'] = found_tool.get('display_name', found_tool['name'])
-            env_vars['TOOL_VERSION'] = tool_version
+            # 3. Deliver the JSON payload through the channel the tool asked for
+            stdin_data = None
+            if input_via_stdin:
+                stdin_data = payload.encode('utf-8')
+            elif payload is not None:
+                os.environ['OPSKIT_INPUT_JSON'] = payload
             
-            # Set environment variables in current process
-            for key, value in env_vars.items():
-                os.environ[key] = str(value)
-            
-            # 2. Run tool with dependency management
-            return self.dependency_manager.run_tool_with_dependencies(found_tool, tool_args)
+            # 4. Run tool with dependency management; only a started run counts for the cooldown
+            return self.dependency_manager.run_tool_with_dependencies(found_tool, tool_args,
+                                                                     prefix_output=prefix_output,
+                                                                     stdin_data=stdin_data,
+                                                                     on_start=lambda: record_tool_run(tool_name))
             
         except Exception as e:
             self._print(f"❌ Error running tool: {e}", "red")
             return 1
+        
+        finally:
+            if lock:
+                lock.close()
+    
+    @staticmethod
+    def _cooldown_remaining(tool: Dict) -> int:
+        """Seconds left before a tool with a cooldown may run again (0 if it may run now)"""
+        cooldown = tool.get('cooldown', 0)
+        last_run = get_last_run(tool['name'])
+        if not cooldown or not last_run:
+            return 0
+        return max(0, int(last_run + cooldown - time.time()))
+    
+    def _show_dry_run(self, tool: Dict, tool_args: List[str], payload: Optional[str] = None) -> int:
+        """Print what running a tool would execute, without installing or running anything"""
+        try:
+            env_vars = self._build_tool_env(tool)
+            for required_tool in self._resolve_required_tools(tool):
+                env_name = self._tool_path_env_name(required_tool['name'])
+                env_vars[env_name] = str(Path(required_tool['path']) / required_tool['main_file'])
+            if payload is not None and tool.get('input_json') != 'stdin':
+                env_vars['OPSKIT_INPUT_JSON'] = payload
+            cmd = self.dependency_manager.build_tool_command(tool, tool_args)
+        except Exception as e:
+            self._print(f"❌ Error preparing tool: {e}", "red")
+            return 1
+        
+        print(f"Tool:        {tool['name']} ({tool['type']})")
+        print(f"Directory:   {tool['path']}")
+        print(f"Command:     {' '.join(shlex.quote(part) for part in cmd)}")
+        if tool.get('timeout'):
+            print(f"Timeout:     {tool['timeout']}s")
+        if payload is not None and tool.get('input_json') == 'stdin':
+            print(f"Stdin:       {payload}")
+        print("Environment:")
+        for key, value in sorted(env_vars.items()):
+            print(f"  {key}={value}")
+        
+        return 0
+    
+    @staticmethod
+    def _tool_path_env_name(tool_name: str) -> str:
+        """Environment variable carrying the main file path of a required tool"""
+        return 'OPSKIT_TOOL_' + tool_name.upper().replace('-', '_') + '_PATH'
+    
+    def run_pipeline(self, stages: List[str], yes: bool = False, force: bool = False) -> int:
+        """
+        Run tools in sequence, piping each tool's stdout into the next tool's stdin
+        
+        Args:
+            stages: Tool names, optionally followed by arguments (e.g. "port-scanner --json")
+            force: Run stages that are disabled or still cooling down
+        
+        Returns:
+            0 if every stage succeeded, otherwise the exit code of the first failed stage
+        """
+        locks = []
+        try:
+            # Status and error output goes to stderr to keep the pipeline's stdout clean
+            with contextlib.redirect_stdout(sys.stderr):
+                pipeline = self._prepare_pipeline(stages, yes, force, locks)
+            if pipeline is None:
+                return 1
+            
+            return self._run_pipeline_stages(pipeline)
+        finally:
+            for lock in locks:
+                lock.close()
+    
+    def _prepare_pipeline(self, stages: List[str], yes: bool, force: bool, locks: List) -> Optional[List]:
+        """
+        Resolve pipeline stages and get them ready to run, taking singleton locks into locks
+        
+        Returns:
+            (tool, args, required_tools) per stage, or None if a stage cannot run
+        """
+        pipeline = []
+        for stage in stages:
+            parts = shlex.split(stage)
+            if not parts:
+                continue
+            tool = self._find_tool(parts[0])
+            if not tool:
+                self._print(f"Tool '{parts[0]}' not found", "red")
+                return None
+            if tool.get('requires_tty'):
+                self._print(f"Tool '{parts[0]}' is interactive and requires a terminal; "
+                            f"it cannot be a pipeline stage", "red")
+                return None
+            if tool.get('disabled'):
+                reason = tool.get('disabled_reason') or 'no reason given'
+                if not force:
+                    self._print(f"Tool '{tool['name']}' is disabled: {reason} "
+                                f"(use 'opskit pipeline --force' to override)", "red")
+                    return None
+                self._print(f"⚠️  Running disabled tool '{tool['name']}' ({reason})", "yellow")
+            remaining = self._cooldown_remaining(tool)
+            if remaining and not force:
+                self._print(f"Tool '{tool['name']}' is cooling down, {remaining} seconds remaining "
+                            f"(use 'opskit pipeline --force' to override)", "yellow")
+                return None
+            try:
+                required_tools = self._resolve_required_tools(tool)
+            except ValueError as e:
+                self._print(f"❌ {e}", "red")
+                return None
+            pipeline.append((tool, parts[1:], required_tools))
+        
+        if not pipeline:
+            self._print("No tools given for the pipeline", "yellow")
+            return None
+        
+        # Confirm every dangerous stage before any stage starts
+        for tool, _, _ in pipeline:
+            if tool.get('dangerous') and not yes and not self._confirm_dangerous(tool):
+                return None
+        
+        # Dependencies, including those of required tools, are prepared up front
+        # so no stage installs mid-stream
+        for tool, _, required_tools in pipeline:
+            for required_tool in required_tools:
+                success, message = self.dependency_manager.ensure_tool_dependencies(required_tool)
+                if not success:
+                    self._print(f"❌ Required tool '{required_tool['name']}' of stage '{tool['name']}' "
+                                f"is not ready: {message}", "red")
+                    return None
+            success, message = self.dependency_manager.ensure_tool_dependencies(tool)
+            if not success:
+                self._print(f"❌ Stage '{tool['name']}' is not ready: {message}", "red")
+                return None
+        
+        for tool, _, _ in pipeline:
+            if tool.get('singleton'):
+                lock, holder_pid = self._acquire_tool_lock(tool['name'])
+                if lock is None:
+                    self._print(f"Tool '{tool['name']}' is already running (pid {holder_pid})", "red")
+                    return None
+                locks.append(lock)
+        
+        return pipeline
+    
+    def _run_pipeline_stages(self, pipeline: List) -> int:
+        """Run prepared (tool, args, required_tools) pipeline stages and report the failed stage"""
+        run_stages = []
+        for tool, args, required_tools in pipeline:
+            stage_env = dict(os.environ)
+            stage_env.update(self._build_tool_env(tool))
+            for required_tool in required_tools:
+                env_name = self._tool_path_env_name(required_tool['name'])
+                stage_env[env_name] = str(Path(required_tool['path']) / required_tool['main_file'])
+            run_stages.append((tool, args, stage_env))
+        
+        exit_code, failed_stage = self.dependency_manager.run_tool_pipeline(
+            run_stages, on_start=lambda tool: record_tool_run(tool['name']))
+        
+        if failed_stage is not None:
+            failed_name = pipeline[failed_stage][0]['name']
+            print(f"❌ Pipeline failed at stage {failed_stage + 1} ({failed_name}) with exit code {exit_code}",
+                  file=sys.stderr)
+        
+        return exit_code
     
-    def search_tools(self, query: str) -> None:
-        """Search tools by name or description"""
+    def _find_tool(self, tool_name: str) -> Optional[Dict]:
+        """Find a discovered tool by name, or by one of its aliases"""
         tools = self.discover_tools()
-        matches = []
         
-        query_lower = query.lower()
+        for cat_tools in tools.values():
+            for tool in cat_tools:
+                if tool['name'] == tool_name:
+                    return tool
         
-        for category, cat_tools in tools.items():
+        # A real tool name always wins over another tool's alias
+        for cat_tools in tools.values():
+            for tool in cat_tools:
+                if tool_name in tool.get('aliases', []):
+                    return tool
+        
+        return None
+    
+    def _resolve_required_tools(self, tool: Dict, chain: Optional[List[str]] = None,
+                                resolved: Optional[List[Dict]] = None) -> List[Dict]:
+        """
+        Resolve requires_tools transitively, dependencies first
+        
+        Raises:
+            ValueError: If a required tool is unknown or requirements form a cycle
+        """
+        chain = (chain or []) + [tool['name']]
+        if resolved is None:
+            resolved = []
+        
+        for required_name in tool.get('requires_tools', []):
+            # Resolve first so requirements given by alias are compared by tool name
+            required_tool = self._find_tool(required_name)
+            if not required_tool:
+                raise ValueError(f"Tool '{tool['name']}' requires unknown tool '{required_name}'")
+            
+            if required_tool['name'] in chain:
+                raise ValueError(f"Circular tool requirement: {' -> '.join(chain + [required_tool['name']])}")
+            
+            if any(required['name'] == required_tool['name'] for required in resolved):
+                continue
+            
+            self._resolve_required_tools(required_tool, chain, resolved)
+            resolved.append(required_tool)
+        
+        return resolved
+    
+    def _inject_tool_env(self, tool: Dict) -> None:
+        """Inject OpsKit and tool environment variables into the current process"""
+        for key, value in self._build_tool_env(tool).items():
+            os.environ[key] = value
+    
+    def _build_tool_env(self, tool: Dict) -> Dict[str, str]:
+        """Build the OpsKit and tool environment variables for a tool"""
+        tool_path = tool['path']
+        
+        # Create tool-specific temporary directory
+        tool_temp_dir = get_tool_temp_dir(tool['name'])
+        
+        # Inject environment variables with tool temp dir and base path;
+        # the tool's env from tools.yaml overrides the defaults for its type
+        # (both expand ${VAR} from the current environment) and the tool's
+        # own .env overrides both
+        env_vars = {key: os.path.expandvars(value)
+                    for key, value in {**tool.get('default_env', {}), **tool.get('env', {})}.items()}
+        env_vars.update(load_tool_env(tool_path))
+        env_vars['OPSKIT_TOOL_TEMP_DIR'] = tool_temp_dir
+        env_vars['OPSKIT_BASE_PATH'] = str(self.opskit_root)
+        
+        # Inject user's working directory (where opskit command was executed)
+        # This allows tools to know the user's actual working directory, not the tool's directory
+        env_vars['OPSKIT_WORKING_DIR'] = os.getcwd()
+        
+        # Inject tool metadata for shell tools
+        env_vars['TOOL_NAME'] = tool.get('display_name', tool['name'])
+        env_vars['TOOL_VERSION'] = tool.get('version', '1.0.0')
+        
+        return {key: str(value) for key, value in env_vars.items()}
+    
+    @staticmethod
+    def _fuzzy_score(term: str, text: str) -> int:
+        """
+        Score term as an in-order subsequence of text (0 if it isn't one)
+        
+        Consecutive characters and characters at the start of a word
+        (e.g. the 's' of 'mysql-sync') score higher, so 'msync' ranks
+        'mysql-sync' above names that merely contain those letters.
+        """
+        score, position, previous = 0, 0, -2
+        for char in term:
+            index = text.find(char, position)
+            if index < 0:
+                return 0
+            score += 1
+            if index == previous + 1:
+                score += 2
+            if index == 0 or text[index - 1] in ' -_./':
+                score += 3
+            previous, position = index, index + 1
+        return score
+    
+    def _match_tools(self, query: str) -> List[Dict]:
+        """
+        Find tools matching the query, best matches first
+        
+        Tools whose name, category, description, keywords or tags contain the
+        query rank highest (name matches first). Otherwise every word of
+        the query must fuzzily match the name, category, a keyword or a tag, so
+        'mysq snc' finds 'mysql-sync'.
+        """
+        query_lower = query.lower().strip()
+        terms = query_lower.split()
+        scored = []
+        
+        for category, cat_tools in self._visible_tools().items():
             for tool in cat_tools:
-                if (query_lower in tool['name'].lower() or 
-                    query_lower in tool['description'].lower()):
-                    matches.append(tool)
+                name = tool['name'].lower()
+                short_fields = [name, category.lower()] + [keyword.lower() for keyword in
+                                                           tool.get('keywords', []) + tool.get('tags', []) +
+                                                           tool.get('aliases', [])]
+                
+                if query_lower in name:
+                    score = 3000 - len(name)
+                elif any(query_lower in field for field in short_fields + [tool['description'].lower()]):
+                    score = 2000
+                else:
+                    term_scores = [max(self._fuzzy_score(term, field) for field in short_fields) for term in terms]
+                    if not term_scores or not all(term_scores):
+                        continue
+                    score = sum(term_scores)
+                
+                scored.append((score, tool))
+        
+        # sorted() is stable, so equal scores keep category / name order
+        return [tool for _, tool in sorted(scored, key=lambda item: -item[0])]
+    
+    @staticmethod
+    def _tool_summary(tool: Dict) -> Dict:
+        """Stable, JSON-serializable view of a tool for machine-readable output"""
+        return {
+            'name': tool['name'],
+            'category': tool['category'],
+            'type': tool['type'],
+            'version': tool.get('version', '1.0.0'),
+            'description': tool['description'],
+            'keywords': tool.get('keywords', []),
+            'tags': tool.get('tags', []),
+            'aliases': tool.get('aliases', []),
+            'dependencies': tool.get('dependencies') or [],
+            'disabled': bool(tool.get('disabled')),
+            'path': str(Path(tool['path']) / tool['main_file']),
+        }
+    
+    def search_tools(self, query: str, with_info: bool = False, as_json: bool = False) -> None:
+        """Search tools by name, category, description, keywords or tags"""
+        matches = self._match_tools(query)
+        
+        if as_json:
+            print(json.dumps([self._tool_summary(tool) for tool in matches], indent=2, ensure_ascii=False))
+            return
         
         if not matches:
             self._print(f"No tools found matching '{query}'")
@@ -361,6 +1020,11 @@ class OpsKitCLI:
         
         self._print(f"Found {len(matches)} tools matching '{query}':")
         
+        if with_info:
+            for tool in matches:
+                self._print_tool_info(tool)
+            return
+        
         if rich_available and self.console:
             table = Table(show_header=True, header_style="bold blue")
             table.add_column("Name", width=20)
@@ -370,7 +1034,7 @@ class OpsKitCLI:
             
             for tool in matches:
                 table.add_row(
-                    tool['name'],
+                    self._display_name(tool),
                     tool['category'],
                     tool['type'],
                     tool['description'][:60] + ('...' if len(tool['description']) > 60 else '')
@@ -379,7 +1043,551 @@ class OpsKitCLI:
             self.console.print(table)
         else:
             for tool in matches:
-                print(f"{tool['name']} ({tool['category']}) - {tool['description']}")
+                print(f"{self._display_name(tool)} ({tool['category']}) - {tool['description']}")
+    
+    def health_check(self, tool_name: Optional[str] = None, as_json: bool = False) -> int:
+        """
+        Run health checks for tools that declare a health_command
+        
+        Returns:
+            0 if every check passed, 1 otherwise
+        """
+        if tool_name:
+            tool = self._find_tool(tool_name)
+            if not tool:
+                self._print(f"Tool '{tool_name}' not found", "red")
+                return 1
+            if not tool.get('health_command'):
+                self._print(f"Tool '{tool_name}' does not declare a health_command", "yellow")
+                return 1
+            candidates = [tool]
+        else:
+            candidates = [tool for cat_tools in self.discover_tools().values()
+                          for tool in cat_tools if tool.get('health_command')]
+        
+        results = []
+        for tool in candidates:
+            # Each check gets its own environment so one tool's env does not leak into the next
+            check_env = dict(os.environ)
+            check_env.update(self._build_tool_env(tool))
+            passed, message = self.dependency_manager.run_tool_health_check(tool, env=check_env)
+            results.append({
+                'name': tool['name'],
+                'category': tool['category'],
+                'status': 'pass' if passed else 'fail',
+                'message': message
+            })
+        
+        failed = [result for result in results if result['status'] == 'fail']
+        
+        if as_json:
+            print(json.dumps({
+                'healthy': not failed,
+                'checked': len(results),
+                'failed': len(failed),
+                'tools': results
+            }, indent=2, ensure_ascii=False))
+            return 1 if failed else 0
+        
+        if not results:
+            self._print("No tools declare a health_command.", "yellow")
+            return 0
+        
+        if rich_available and self.console:
+            table = Table(show_header=True, header_style="bold blue")
+            table.add_column("Tool", width=20)
+            table.add_column("Status", width=8)
+            table.add_column("Details")
+            
+            for result in results:
+                status = "[green]pass[/green]" if result['status'] == 'pass' else "[red]fail[/red]"
+                table.add_row(result['name'], status, result['message'])
+            
+            self.console.print(table)
+        else:
+            for result in results:
+                print(f"{result['name']}: {result['status']} - {result['message']}")
+        
+        if failed:
+            self._print(f"❌ {len(failed)} of {len(results)} health checks failed", "red")
+            return 1
+        
+        self._print(f"✅ All {len(results)} health checks passed", "green")
+        return 0
+    
+    def _doctor_checks(self) -> List[Dict]:
+        """Run the `opskit doctor` checks; status is pass, warn or fail (fail makes doctor exit 1)"""
+        checks = []
+        
+        def add(name: str, status: str, message: str) -> None:
+            checks.append({'name': name, 'status': status, 'message': message})
+        
+        version = '.'.join(str(part) for part in sys.version_info[:3])
+        add("Python", 'pass' if sys.version_info >= (3, 7) else 'fail',
+            f"{version} ({sys.executable})" if sys.version_info >= (3, 7) else f"{version}; OpsKit needs 3.7+")
+        
+        for label, directory in (("Data directory", env.data_dir), ("Cache directory", env.cache_dir)):
+            path = Path(directory)
+            try:
+                path.mkdir(parents=True, exist_ok=True)
+                writable = os.access(path, os.W_OK)
+            except OSError:
+                writable = False
+            add(label, 'pass' if writable else 'fail', f"{path} {'is writable' if writable else 'is not writable'}")
+        
+        # Config files: parse errors break every tool, validation errors break some
+        try:
+            config = load_yaml_file(self.opskit_root / 'config' / 'tools.yaml', 'config/tools.yaml')
+            errors, warnings = self._validate_tools_config(config)
+            if errors:
+                add("tools.yaml", 'fail', f"{len(errors)} error(s); run 'opskit validate' for details")
+            elif warnings:
+                add("tools.yaml", 'warn', f"{len(warnings)} warning(s); run 'opskit validate' for details")
+            else:
+                add("tools.yaml", 'pass', "valid")
+        except ConfigError as e:
+            add("tools.yaml", 'fail', str(e).splitlines()[0])
+        
+        try:
+            load_yaml_file(self.opskit_root / 'config' / 'dependencies.yaml', 'config/dependencies.yaml')
+            add("dependencies.yaml", 'pass', "parses")
+        except ConfigError as e:
+            add("dependencies.yaml", 'fail', str(e).splitlines()[0])
+        
+        # Created on demand by the first tool with a requirements.txt
+        if self.dependency_manager.shared_venv.exists():
+            ok, message = self.dependency_manager.validate_venv_integrity()
+            add("Shared venv", 'pass' if ok else 'fail',
+                str(self.dependency_manager.shared_venv) if ok else f"{message}; remove it to have it recreated")
+        else:
+            add("Shared venv", 'warn', "not created yet (made on the first run of a tool with requirements.txt)")
+        
+        manager = self.dependency_manager._get_preferred_package_manager()
+        add("Package manager", 'pass' if manager else 'warn',
+            manager or "none detected; missing system dependencies must be installed by hand")
+        
+        tools = [tool for cat_tools in self.discover_tools().values() for tool in cat_tools]
+        unstartable = [f"{tool['name']} ({problem})" for tool in tools
+                       for problem in [self.dependency_manager.check_tool_interpreter(tool)] if problem]
+        add("Tool interpreters", 'warn' if unstartable else 'pass',
+            '; '.join(unstartable) if unstartable else f"all {len(tools)} tools can start")
+        
+        if not shutil.which('git') or not (self.opskit_root / '.git').exists():
+            add("Updates", 'warn', "git or the .git directory is missing; 'opskit update' is unavailable")
+        elif env.offline:
+            add("Updates", 'warn', "OPSKIT_OFFLINE is set; upstream not checked")
+        else:
+            try:
+                result = subprocess.run(['git', 'ls-remote', '--exit-code', '--heads'],
+                                        cwd=self.opskit_root, capture_output=True, text=True, timeout=15)
+                if result.returncode == 0:
+                    add("Updates", 'pass', "upstream repository is reachable")
+                else:
+                    add("Updates", 'warn', f"cannot reach upstream: {(result.stderr.strip() or 'no remote configured').splitlines()[0]}")
+            except subprocess.TimeoutExpired:
+                add("Updates", 'warn', "timed out reaching upstream")
+        
+        return checks
+    
+    def doctor(self, as_json: bool = False) -> int:
+        """
+        Diagnose the OpsKit installation
+        
+        Returns:
+            1 if any critical check failed, otherwise 0
+        """
+        checks = self._doctor_checks()
+        failed = [check for check in checks if check['status'] == 'fail']
+        
+        if as_json:
+            print(json.dumps({'healthy': not failed, 'checks': checks}, indent=2, ensure_ascii=False))
+            return 1 if failed else 0
+        
+        styles = {'pass': 'green', 'warn': 'yellow', 'fail': 'red'}
+        if rich_available and self.console:
+            table = Table(show_header=True, header_style="bold blue")
+            table.add_column("Check", width=20)
+            table.add_column("Status", width=8)
+            table.add_column("Details")
+            
+            for check in checks:
+                style = styles[check['status']]
+                table.add_row(check['name'], f"[{style}]{check['status']}[/{style}]", escape(check['message']))
+            
+            self.console.print(table)
+        else:
+            for check in checks:
+                print(f"{check['name']}: {check['status']} - {check['message']}")
+        
+        if failed:
+            self._print(f"❌ {len(failed)} critical check(s) failed", "red")
+            return 1
+        
+        self._print("✅ No critical problems found", "green")
+        return 0
+    
+    def _resolve_dependencies(self, tool: Dict) -> List[Dict]:
+        """Look up a tool's declared system dependencies in config/dependencies.yaml"""
+        system_deps = self.dependency_manager.dependencies_config.get('system_dependencies', {})
+        dependencies = []
+        for dep_name in tool.get('dependencies') or []:
+            dep_config = system_deps.get(dep_name) or {}
+            dependencies.append({
+                'name': dep_name,
+                'description': dep_config.get('description', ''),
+                'commands': dep_config.get('commands') or [],
+            })
+        return dependencies
+    
+    # Fields _parse_tool_info understands, by expected kind
+    _TOOL_FIELD_KINDS = {
+        'version': 'scalar', 'description': 'scalar', 'health_command': 'scalar', 'docs': 'scalar',
+        'disabled_reason': 'scalar', 'confirm_prompt': 'scalar',
+        'keywords': 'list', 'tags': 'list', 'aliases': 'list', 'dependencies': 'list', 'requires_tools': 'list',
+        'visible_if': 'list_or_scalar',
+        'cooldown': 'duration', 'timeout': 'duration',
+        'requires_tty': 'bool', 'singleton': 'bool', 'disabled': 'bool', 'dangerous': 'bool',
+        'input_json': 'input_json', 'env': 'mapping',
+    }
+    
+    @staticmethod
+    def _is_duration(value) -> bool:
+        """Check that a value is something _parse_duration understands"""
+        if isinstance(value, bool):
+            return False
+        if isinstance(value, (int, float)):
+            return value >= 0
+        value = str(value).strip().lower()
+        if value and value[-1] in 'smhd':
+            value = value[:-1]
+        try:
+            return float(value) >= 0
+        except ValueError:
+            return False
+    
+    def _validate_tool_fields(self, where: str, tool_config: Dict) -> List[str]:
+        """Problems with the fields of one tool entry in tools.yaml"""
+        problems = []
+        for field, value in tool_config.items():
+            kind = self._TOOL_FIELD_KINDS.get(field)
+            if kind is None:
+                problems.append(f"{where}: unknown field '{field}'")
+            elif value is None:
+                continue
+            elif kind == 'list' and not isinstance(value, list):
+                problems.append(f"{where}.{field}: expected a list")
+            elif kind == 'list_or_scalar' and isinstance(value, dict):
+                problems.append(f"{where}.{field}: expected a command name or a list of them")
+            elif kind == 'scalar' and isinstance(value, (list, dict)):
+                problems.append(f"{where}.{field}: expected a single value")
+            elif kind == 'bool' and not isinstance(value, bool):
+                problems.append(f"{where}.{field}: expected true or false")
+            elif kind == 'duration' and not self._is_duration(value):
+                problems.append(f"{where}.{field}: '{value}' is not a duration (e.g. 30, '30s', '5m', '1h'); "
+                                f"it is ignored")
+            elif kind == 'input_json' and value not in ('env', 'stdin'):
+                problems.append(f"{where}.{field}: expected 'env' or 'stdin'")
+            elif kind == 'mapping' and not isinstance(value, dict):
+                problems.append(f"{where}.{field}: expected a mapping of names to values")
+        return problems
+    
+    def _validate_tools_config(self, config: Dict) -> Tuple[List[str], List[str]]:
+        """
+        Check a parsed tools.yaml against the tool directories and dependencies.yaml
+        
+        Returns:
+            Tuple of (errors, warnings)
+        """
+        errors, warnings = [], []
+        tools = config.get('tools')
+        if not isinstance(tools, dict):
+            return ["'tools' must be a mapping of categories to tools"], warnings
+        
+        categories = config.get('categories') or {}
+        known_deps = set((self.dependency_manager.dependencies_config or {}).get('system_dependencies') or {})
+        tools_dir = self.opskit_root / 'tools'
+        main_files = [f'main{ext}' for ext in TOOL_TYPES]
+        
+        names = {}
+        aliases = {}
+        requirements = []
+        for category, cat_tools in tools.items():
+            if categories and category not in categories:
+                warnings.append(f"tools.{category}: category is not defined under 'categories'")
+            if not isinstance(cat_tools, dict):
+                errors.append(f"tools.{category}: expected a mapping of tool names to tool settings")
+                continue
+            
+            for tool_name, tool_config in cat_tools.items():
+                where = f"tools.{category}.{tool_name}"
+                tool_config = tool_config or {}
+                if not isinstance(tool_config, dict):
+                    errors.append(f"{where}: expected a mapping of tool settings")
+                    continue
+                
+                if tool_name in names:
+                    errors.append(f"{where}: duplicate tool name (also under tools.{names[tool_name]})")
+                names[tool_name] = category
+                
+                tool_dir = tools_dir / category / tool_name
+                if not tool_dir.is_dir():
+                    errors.append(f"{where}: no tool directory tools/{category}/{tool_name}")
+                elif not any((tool_dir / name).exists() or (tool_dir / f'{tool_name}{Path(name).suffix}').exists()
+                             for name in main_files):
+                    errors.append(f"{where}: no main file ({', '.join(main_files)}) in tools/{category}/{tool_name}")
+                
+                errors.extend(self._validate_tool_fields(where, tool_config))
+                
+                dependencies = tool_config.get('dependencies')
+                for dep_name in dependencies if isinstance(dependencies, list) else []:
+                    if dep_name not in known_deps:
+                        warnings.append(f"{where}: dependency '{dep_name}' is not defined in "
+                                        f"config/dependencies.yaml, so it is never checked")
+                
+                if isinstance(tool_config.get('aliases'), list):
+                    for alias in tool_config['aliases']:
+                        if alias in aliases and aliases[alias] != tool_name:
+                            errors.append(f"{where}: alias '{alias}' is also an alias of {aliases[alias]}")
+                        aliases[alias] = tool_name
+                
+                if isinstance(tool_config.get('requires_tools'), list):
+                    requirements.extend((where, required) for required in tool_config['requires_tools'])
+        
+        for alias, tool_name in aliases.items():
+            if alias in names:
+                warnings.append(f"alias '{alias}' of {tool_name} is also a tool name; the tool wins")
+        
+        for where, required in requirements:
+            if required not in names and required not in aliases:
+                errors.append(f"{where}.requires_tools: unknown tool '{required}'")
+        
+        for tool_type, defaults in (config.get('type_defaults') or {}).items():
+            where = f"type_defaults.{tool_type}"
+            if tool_type not in TOOL_TYPES.values():
+                errors.append(f"{where}: unknown tool type (expected one of {', '.join(TOOL_TYPES.values())})")
+            if not isinstance(defaults or {}, dict):
+                errors.append(f"{where}: expected a mapping of settings")
+                continue
+            defaults = defaults or {}
+            for field in ('args', 'interpreters'):
+                if defaults.get(field) is not None and not isinstance(defaults[field], list):
+                    errors.append(f"{where}.{field}: expected a list")
+            if defaults.get('env') is not None and not isinstance(defaults['env'], dict):
+                errors.append(f"{where}.env: expected a mapping of names to values")
+            if 'timeout' in defaults and not self._is_duration(defaults['timeout']):
+                errors.append(f"{where}.timeout: '{defaults['timeout']}' is not a duration")
+        
+        # Tool directories without an entry still run, just with default metadata
+        if tools_dir.is_dir():
+            for tool_dir in sorted(tools_dir.glob('*/*')):
+                if tool_dir.is_dir() and tool_dir.name not in (tools.get(tool_dir.parent.name) or {}):
+                    warnings.append(f"tools/{tool_dir.parent.name}/{tool_dir.name}: not listed in tools.yaml")
+        
+        return errors, warnings
+    
+    def validate_config(self, path: Optional[str] = None) -> int:
+        """
+        Validate tools.yaml (config/tools.yaml unless a path is given)
+        
+        Returns:
+            0 if no errors were found (warnings allowed), 1 otherwise
+        """
+        config_path = Path(path) if path else self.opskit_root / 'config' / 'tools.yaml'
+        label = path or 'config/tools.yaml'
+        try:
+            config = load_yaml_file(config_path, label)
+        except ConfigError as e:
+            self._print(f"❌ {e}", "red")
+            return 1
+        
+        errors, warnings = self._validate_tools_config(config)
+        for problem in errors:
+            self._print(f"❌ {problem}", "red")
+        for problem in warnings:
+            self._print(f"⚠️  {problem}", "yellow")
+        
+        if errors:
+            self._print(f"{label}: {len(errors)} error(s), {len(warnings)} warning(s)", "red")
+            return 1
+        self._print(f"✅ {label} is valid" + (f" ({len(warnings)} warning(s))" if warnings else ""), "green")
+        return 0
+    
+    def export_catalog(self, export_format: str = 'markdown', output: Optional[str] = None) -> int:
+        """
+        Export every tool as reference documentation
+        
+        Categories and tools are sorted and paths are relative to the OpsKit
+        root, so the output only changes when the catalog does.
+        
+        Args:
+            export_format: 'markdown', 'html' or 'json'
+            output: File to write; stdout when not given
+        """
+        catalog = []
+        tools = self.discover_tools()
+        for category in sorted(tools):
+            entries = []
+            for tool in sorted(tools[category], key=lambda t: t['name']):
+                entry = self._tool_summary(tool)
+                entry['path'] = os.path.relpath(entry['path'], self.opskit_root)
+                entry['dependencies'] = self._resolve_dependencies(tool)
+                entry['requires_tools'] = tool.get('requires_tools') or []
+                entries.append(entry)
+            catalog.append({'category': category, 'tools': entries})
+        
+        renderers = {
+            'json': lambda: json.dumps({'categories': catalog}, indent=2, ensure_ascii=False) + '\n',
+            'markdown': lambda: self._render_catalog_markdown(catalog),
+            'html': lambda: self._render_catalog_html(catalog),
+        }
+        content = renderers[export_format]()
+        
+        if not output:
+            sys.stdout.write(content)
+            return 0
+        
+        try:
+            Path(output).write_text(content, encoding='utf-8')
+        except OSError as e:
+            self._print(f"❌ Failed to write {output}: {e}", "red")
+            return 1
+        
+        self._print(f"✅ Exported {sum(len(c['tools']) for c in catalog)} tools to {output}", "green")
+        return 0
+    
+    @staticmethod
+    def _render_catalog_markdown(catalog: List[Dict]) -> str:
+        """Render the tool catalog as Markdown"""
+        lines = ["# OpsKit Tools", ""]
+        for group in catalog:
+            lines += [f"## {group['category']}", ""]
+            for tool in group['tools']:
+                lines += [f"### {tool['name']}", "", tool['description'], ""]
+                lines.append(f"- **Version**: {tool['version']}")
+                lines.append(f"- **Type**: {tool['type']}")
+                lines.append(f"- **Path**: `{tool['path']}`")
+                lines.append(f"- **Run**: `opskit run {tool['name']}`")
+                if tool['keywords']:
+                    lines.append(f"- **Keywords**: {', '.join(tool['keywords'])}")
+                if tool['tags']:
+                    lines.append(f"- **Tags**: {', '.join(tool['tags'])}")
+                if tool['requires_tools']:
+                    lines.append(f"- **Requires tools**: {', '.join(tool['requires_tools'])}")
+                if tool['dependencies']:
+                    lines.append("- **Dependencies**:")
+                    for dep in tool['dependencies']:
+                        commands = f" (`{'`, `'.join(dep['commands'])}`)" if dep['commands'] else ''
+                        lines.append(f"  - {dep['name']}: {dep['description']}{commands}")
+                lines.append("")
+        return '\n'.join(lines)
+    
+    @staticmethod
+    def _render_catalog_html(catalog: List[Dict]) -> str:
+        """Render the tool catalog as a standalone HTML page"""
+        parts = ['<!DOCTYPE html>', '<html>', '<head>', '<meta charset="utf-8">',
+                 '<title>OpsKit Tools</title>', '</head>', '<body>', '<h1>OpsKit Tools</h1>']
+        for group in catalog:
+            parts.append(f"<h2>{html_escape(group['category'])}</h2>")
+            for tool in group['tools']:
+                parts.append(f"<h3>{html_escape(tool['name'])}</h3>")
+                parts.append(f"<p>{html_escape(tool['description'])}</p>")
+                parts.append('<ul>')
+                parts.append(f"<li><strong>Version</strong>: {html_escape(tool['version'])}</li>")
+                parts.append(f"<li><strong>Type</strong>: {html_escape(tool['type'])}</li>")
+                parts.append(f"<li><strong>Path</strong>: <code>{html_escape(tool['path'])}</code></li>")
+                parts.append(f"<li><strong>Run</strong>: <code>opskit run {html_escape(tool['name'])}</code></li>")
+                if tool['keywords']:
+                    parts.append(f"<li><strong>Keywords</strong>: {html_escape(', '.join(tool['keywords']))}</li>")
+                if tool['tags']:
+                    parts.append(f"<li><strong>Tags</strong>: {html_escape(', '.join(tool['tags']))}</li>")
+                if tool['requires_tools']:
+                    parts.append(f"<li><strong>Requires tools</strong>: "
+                                 f"{html_escape(', '.join(tool['requires_tools']))}</li>")
+                if tool['dependencies']:
+                    parts.append('<li><strong>Dependencies</strong>:<ul>')
+                    for dep in tool['dependencies']:
+                        commands = f" (<code>{html_escape(', '.join(dep['commands']))}</code>)" if dep['commands'] else ''
+                        parts.append(f"<li>{html_escape(dep['name'])}: {html_escape(dep['description'])}{commands}</li>")
+                    parts.append('</ul></li>')
+                parts.append('</ul>')
+        parts += ['</body>', '</html>', '']
+        return '\n'.join(parts)
+    
+    def show_tool_info(self, tool_name: str, as_json: bool = False) -> int:
+        """
+        Show a tool's full metadata and the system dependencies it declares
+        
+        Returns:
+            0 if the tool was found, 1 otherwise
+        """
+        tool = self._find_tool(tool_name)
+        if not tool:
+            if as_json:
+                print(json.dumps({'error': f"Tool '{tool_name}' not found"}))
+            else:
+                self._print(f"Tool '{tool_name}' not found", "red")
+            return 1
+        
+        dependencies = self._resolve_dependencies(tool)
+        for dep in dependencies:
+            dep['available'] = all(shutil.which(command) for command in dep['commands']) if dep['commands'] else None
+        
+        if as_json:
+            info = dict(tool)
+            info['dependencies'] = dependencies
+            print(json.dumps(info, indent=2, ensure_ascii=False))
+            return 0
+        
+        self._print_tool_info(tool)
+        if not dependencies:
+            return 0
+        
+        status_labels = {True: "✅ available", False: "❌ missing", None: "unknown"}
+        if rich_available and self.console:
+            table = Table(show_header=True, header_style="bold blue", title="System Dependencies")
+            table.add_column("Dependency")
+            table.add_column("Description")
+            table.add_column("Commands")
+            table.add_column("Status")
+            for dep in dependencies:
+                table.add_row(dep['name'], dep['description'], ', '.join(dep['commands']),
+                              status_labels[dep['available']])
+            self.console.print(table)
+        else:
+            print("System dependencies:")
+            for dep in dependencies:
+                print(f"  {dep['name']} - {dep['description']} "
+                      f"[{', '.join(dep['commands'])}] {status_labels[dep['available']]}")
+        
+        return 0
+    
+    def show_tool_docs(self, tool_name: str, print_only: bool = False) -> int:
+        """Open a tool's documentation in the browser, or print its location"""
+        tool = self._find_tool(tool_name)
+        if not tool:
+            self._print(f"Tool '{tool_name}' not found", "red")
+            return 1
+        
+        docs = tool.get('docs')
+        if not docs:
+            self._print(f"No documentation available for '{tool_name}'", "yellow")
+            return 1
+        
+        url = docs if '://' in docs else Path(docs).resolve().as_uri()
+        
+        if not print_only and self.platform_utils.open_url(url):
+            self._print(f"Opened documentation for {tool_name}: {docs}", "green")
+        else:
+            print(docs)
+        
+        # Surface documentation of declared system dependencies as well
+        system_deps = self.dependency_manager.dependencies_config.get('system_dependencies', {})
+        for dep_name in tool.get('dependencies', []):
+            dep_docs = system_deps.get(dep_name, {}).get('docs')
+            if dep_docs:
+                self._print(f"  {dep_name}: {dep_docs}", "dim")
+        
+        return 0
     
     def show_status(self) -> None:
         """Show system status"""
@@ -460,12 +1668,20 @@ class OpsKitCLI:
         if self._confirm("Modify settings?", False):
             self.settings_wizard(is_first_run_setup=False)
     
-    def update_opskit(self) -> None:
+    def update_opskit(self, dry_run: bool = False) -> None:
         """Update OpsKit using git pull"""
         if not (self.opskit_root / '.git').exists():
             self._print("OpsKit is not a git repository. Cannot update automatically.", "red")
             return
         
+        if env.offline:
+            self._print("OPSKIT_OFFLINE is set. Unset it to update OpsKit.", "red")
+            return
+        
+        if dry_run:
+            self._preview_update()
+            return
+        
         if self._confirm("Update OpsKit to the latest version?"):
             try:
                 self._print("Updating OpsKit...", "blue")
@@ -486,6 +1702,7 @@ class OpsKitCLI:
                     
                     # Clear tool cache to reflect any changes
                     self._tool_cache = None
+                    self._tools_config = None
                 else:
                     self._print(f"Update failed: {result.stderr}", "red")
             
@@ -494,6 +1711,139 @@ class OpsKitCLI:
             except Exception as e:
                 self._print(f"Update error: {e}", "red")
     
+    def _preview_update(self) -> None:
+        """Fetch upstream and show what `opskit update` would change, without merging"""
+        try:
+            self._print("Checking for updates...", "blue")
+            result = subprocess.run(
+                ['git', 'fetch', '--quiet'],
+                cwd=self.opskit_root,
+                capture_output=True,
+                text=True,
+                timeout=60
+            )
+            if result.returncode != 0:
+                self._print(f"Fetch failed: {result.stderr}", "red")
+                return
+            
+            result = subprocess.run(
+                ['git', 'log', '--oneline', 'HEAD..@{u}'],
+                cwd=self.opskit_root,
+                capture_output=True,
+                text=True,
+                timeout=30
+            )
+            if result.returncode != 0:
+                self._print(f"Cannot compare with upstream: {result.stderr}", "red")
+                return
+            
+            commits = result.stdout.strip().splitlines()
+            if not commits:
+                self._print("OpsKit is already up to date.", "green")
+                return
+            
+            result = subprocess.run(
+                ['git', 'diff', '--name-only', 'HEAD...@{u}'],
+                cwd=self.opskit_root,
+                capture_output=True,
+                text=True,
+                timeout=30
+            )
+            changed_files = result.stdout.strip().splitlines()
+            
+            # Group changed files by tool (tools/<category>/<tool>/...)
+            changed_tools = sorted({'/'.join(path.split('/')[1:3]) for path in changed_files
+                                    if path.startswith('tools/') and path.count('/') >= 3})
+            other_files = [path for path in changed_files if not path.startswith('tools/')]
+            
+            preview = f"{len(commits)} new commit(s):\n"
+            preview += "\n".join(f"  {commit}" for commit in commits)
+            if changed_tools:
+                preview += "\n\nTools changed:\n" + "\n".join(f"  {tool}" for tool in changed_tools)
+            if other_files:
+                preview += "\n\nOther files changed:\n" + "\n".join(f"  {path}" for path in other_files)
+            
+            self._print_panel(preview, "Update Preview (dry run)", "cyan")
+            self._print("Run 'opskit update' to apply.", "dim")
+        
+        except subprocess.TimeoutExpired:
+            self._print("Update check timed out. Please try again.", "red")
+        except Exception as e:
+            self._print(f"Update check error: {e}", "red")
+    
+    def diff_config(self) -> int:
+        """Show a structured diff of config/tools.yaml between the checkout and its upstream branch"""
+        if not (self.opskit_root / '.git').exists():
+            self._print("OpsKit is not a git repository. Cannot compare with upstream.", "red")
+            return 1
+        
+        try:
+            # Offline, compare against whatever upstream was last fetched
+            if env.offline:
+                self._print("OPSKIT_OFFLINE is set, comparing with the last fetched upstream.", "dim")
+            else:
+                result = subprocess.run(['git', 'fetch', '--quiet'], cwd=self.opskit_root,
+                                        capture_output=True, text=True, timeout=60)
+                if result.returncode != 0:
+                    self._print(f"Fetch failed: {result.stderr}", "red")
+                    return 1
+            
+            result = subprocess.run(['git', 'show', '@{u}:config/tools.yaml'], cwd=self.opskit_root,
+                                    capture_output=True, text=True, timeout=30)
+            if result.returncode != 0:
+                self._print(f"Cannot read upstream tools.yaml: {result.stderr}", "red")
+                return 1
+            remote_config = yaml.safe_load(result.stdout) or {}
+        except subprocess.TimeoutExpired:
+            self._print("Upstream comparison timed out. Please try again.", "red")
+            return 1
+        except yaml.YAMLError as e:
+            self._print("Upstream tools.yaml is not valid YAML:", "red")
+            print(describe_yaml_error(e, result.stdout, '@{u}:config/tools.yaml'))
+            return 1
+        
+        local_config = self._load_tools_config()
+        
+        def flatten(config: Dict) -> Dict[str, Dict]:
+            return {f"{category}/{name}": info or {}
+                    for category, cat_tools in (config.get('tools') or {}).items()
+                    for name, info in (cat_tools or {}).items()}
+        
+        def normalize(value):
+            # Lists such as keywords and dependencies are unordered sets of names
+            if isinstance(value, list) and all(isinstance(item, str) for item in value):
+                return sorted(value)
+            return value
+        
+        local_tools = flatten(local_config)
+        remote_tools = flatten(remote_config)
+        
+        added = sorted(set(remote_tools) - set(local_tools))
+        removed = sorted(set(local_tools) - set(remote_tools))
+        changed = {}
+        for tool_id in sorted(set(local_tools) & set(remote_tools)):
+            local_info, remote_info = local_tools[tool_id], remote_tools[tool_id]
+            fields = sorted(field for field in set(local_info) | set(remote_info)
+                            if normalize(local_info.get(field)) != normalize(remote_info.get(field)))
+            if fields:
+                changed[tool_id] = [(field, local_info.get(field), remote_info.get(field)) for field in fields]
+        
+        if not (added or removed or changed):
+            self._print("Local tools.yaml matches upstream.", "green")
+            return 0
+        
+        self._print("Upstream vs local config/tools.yaml:", "bold blue")
+        for tool_id in added:
+            self._print(f"  + {tool_id}  (only upstream)", "green")
+        for tool_id in removed:
+            self._print(f"  - {tool_id}  (only local)", "red")
+        for tool_id, fields in changed.items():
+            self._print(f"  ~ {tool_id}", "yellow")
+            for field, local_value, remote_value in fields:
+                self._print(f"      {field}: {local_value!r} -> {remote_value!r}")
+        
+        return 0
+    
     def generate_completion(self, shell: str) -> None:
         """Generate shell completion script using Click's built-in functionality"""
         from pathlib import Path
@@ -551,14 +1901,29 @@ For more information, visit: https://github.com/monlor/opskit
 """
         self._print_panel(help_text.strip(), "Help", "cyan")
 
-    def clean_cache(self, service: Optional[str] = None, clean_all: bool = False) -> None:
+    # Subdirectories OpsKit creates in its cache dir; a cache dir outside the
+    # OpsKit directory may hold other data, so only these are removed there
+    _CACHE_SUBDIRS = ('tools', 'venvs', 'requirements', 'pip_cache', 'downloads', 'locks')
+
+    def clean_cache(self, service: Optional[str] = None, clean_all: bool = False, dry_run: bool = False) -> None:
         """Clean cache directory, optionally for a specific service/tool.
 
         Args:
             service: Tool/service name to clean (under cache/tools/<service>)
-            clean_all: If True, remove the entire cache directory
+            clean_all: If True, remove the entire cache directory (only OpsKit's
+                subdirectories of a cache dir outside the OpsKit directory)
+            dry_run: Only list what would be deleted
         """
         cache_dir = Path(env.cache_dir)
+        resolved_cache_dir = cache_dir.resolve()
+
+        # Never delete outside the OpsKit directory, except OpsKit's own
+        # subdirectories of an absolute OPSKIT_PATHS_CACHE_DIR
+        inside_opskit = self._is_safe_to_delete(resolved_cache_dir)
+        for protected in (Path.home().resolve(), self.opskit_root.resolve()):
+            if resolved_cache_dir == protected or resolved_cache_dir in protected.parents:
+                self._print(f"❌ Refusing to clean cache at '{cache_dir}': it is or contains {protected}", "red")
+                return
 
         # Ensure cache path is resolved from env.py (not hardcoded)
         if clean_all:
@@ -566,6 +1931,14 @@ For more information, visit: https://github.com/monlor/opskit
                 self._print(f"No cache directory found at: {cache_dir}", "yellow")
                 return
 
+            if not inside_opskit:
+                self._clean_cache_subdirs(cache_dir, dry_run)
+                return
+
+            if dry_run:
+                self._print_deletion_preview(sorted(cache_dir.iterdir()))
+                return
+
             if not self._confirm(f"This will delete ALL cache at '{cache_dir}'. Continue?", False):
                 self._print("Cancelled.", "yellow")
                 return
@@ -583,11 +1956,20 @@ For more information, visit: https://github.com/monlor/opskit
             self._print("Please specify a service (tool name) or use --all to clear all cache.", "yellow")
             return
 
+        # Checked against the unresolved tools dir so a symlinked one cannot lead elsewhere
         service_cache_dir = cache_dir / 'tools' / service
+        if not self._is_safe_to_delete(service_cache_dir.resolve(), within=resolved_cache_dir / 'tools'):
+            self._print(f"❌ Refusing to clean '{service_cache_dir}': it is not inside {cache_dir / 'tools'}", "red")
+            return
+
         if not service_cache_dir.exists():
             self._print(f"No cache found for service '{service}' at: {service_cache_dir}", "yellow")
             return
 
+        if dry_run:
+            self._print_deletion_preview([service_cache_dir])
+            return
+
         if not self._confirm(f"Delete cache for service '{service}' at '{service_cache_dir}'?", False):
             self._print("Cancelled.", "yellow")
             return
@@ -597,3 +1979,60 @@ For more information, visit: https://github.com/monlor/opskit
             self._print(f"✅ Cleared cache for service '{service}'", "green")
         except Exception as e:
             self._print(f"❌ Failed to clear service cache: {e}", "red")
+
+    def _clean_cache_subdirs(self, cache_dir: Path, dry_run: bool) -> None:
+        """Remove OpsKit's own subdirectories of a cache dir outside the OpsKit directory"""
+        resolved_cache_dir = cache_dir.resolve()
+        # Symlinked subdirectories point elsewhere and are left alone
+        targets = [cache_dir / name for name in self._CACHE_SUBDIRS
+                   if (cache_dir / name).is_dir() and (cache_dir / name).resolve() == resolved_cache_dir / name]
+
+        if dry_run:
+            self._print_deletion_preview(targets)
+            return
+
+        if not targets:
+            self._print(f"No OpsKit cache found at: {cache_dir}", "yellow")
+            return
+
+        names = ', '.join(target.name for target in targets)
+        if not self._confirm(f"This will delete OpsKit's cache ({names}) in '{cache_dir}'. Continue?", False):
+            self._print("Cancelled.", "yellow")
+            return
+
+        try:
+            for target in targets:
+                shutil.rmtree(target)
+            self._print(f"✅ Cleared OpsKit's cache in: {cache_dir}", "green")
+        except Exception as e:
+            self._print(f"❌ Failed to clear cache: {e}", "red")
+
+    def _is_safe_to_delete(self, path: Path, within: Optional[Path] = None) -> bool:
+        """Check that a resolved path lies strictly inside `within` (default: the OpsKit root)"""
+        within = within or self.opskit_root.resolve()
+        return path != within and within in path.parents
+
+    def _print_deletion_preview(self, paths: List[Path]) -> None:
+        """List paths a clean would delete, with their sizes"""
+        if not paths:
+            self._print("Nothing to delete.", "yellow")
+            return
+
+        total = 0
+        for path in paths:
+            if path.is_dir():
+                size = sum(f.stat().st_size for f in path.rglob('*') if f.is_file() and not f.is_symlink())
+            else:
+                size = path.lstat().st_size
+            total += size
+            self._print(f"Would delete: {path} ({self._format_size(size)})")
+
+        self._print(f"Total: {self._format_size(total)} (dry run, nothing deleted)", "yellow")
+
+    @staticmethod
+    def _format_size(size: int) -> str:
+        """Format a byte count for display"""
+        for unit in ('B', 'KB', 'MB', 'GB'):
+            if size < 1024 or unit == 'GB':
+                return f"{size:.0f} {unit}" if unit == 'B' else f"{size:.1f} {unit}"
+            size /= 1024
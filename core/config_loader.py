@@ -0,0 +1,92 @@
+"""
+Config Loader
+
+Loads OpsKit's YAML config files (config/tools.yaml, config/dependencies.yaml)
+and turns parse errors into messages that point at the offending line.
+
+Usage:
+    from core.config_loader import load_yaml_file, ConfigError
+
+    try:
+        config = load_yaml_file(path)
+    except ConfigError as e:
+        print(e)
+"""
+
+from pathlib import Path
+from typing import Dict
+
+import yaml
+
+
+class ConfigError(Exception):
+    """A config file could not be read or parsed"""
+
+
+def describe_yaml_error(error: yaml.YAMLError, text: str, label: str) -> str:
+    """
+    Describe a YAML parse error as 'label:line:column: problem' plus the offending line
+
+    Args:
+        error: Error raised by yaml.safe_load
+        text: The YAML document that failed to parse
+        label: File name to show in the message
+    """
+    problem_mark = getattr(error, 'problem_mark', None)
+    context_mark = getattr(error, 'context_mark', None)
+    mark = problem_mark or context_mark
+    if mark is None:
+        return f"{label}: {error}"
+
+    problem = getattr(error, 'problem', None) or str(error)
+    context = getattr(error, 'context', None)
+    if context:
+        problem = f"{problem} ({context})"
+
+    message = f"{label}:{mark.line + 1}:{mark.column + 1}: {problem}"
+
+    # The construct being parsed often starts on an earlier line than the
+    # point where parsing failed (e.g. a key missing its ':'), so show both
+    marks = [mark]
+    if context_mark is not None and mark is problem_mark and context_mark.line != mark.line:
+        marks.insert(0, context_mark)
+
+    lines = text.splitlines()
+    for snippet_mark in marks:
+        if 0 <= snippet_mark.line < len(lines):
+            gutter = f"{snippet_mark.line + 1:>5} | "
+            message += f"\n{gutter}{lines[snippet_mark.line]}"
+            message += f"\n{' ' * (len(gutter) - 2)}| {' ' * snippet_mark.column}^"
+
+    return message
+
+
+def load_yaml_file(path: Path, label: str = None) -> Dict:
+    """
+    Load a YAML mapping from a file
+
+    Returns:
+        Parsed document, or {} for an empty file
+
+    Raises:
+        ConfigError: The file cannot be read, is not valid YAML or is not a mapping
+    """
+    label = label or str(path)
+
+    try:
+        with open(path, 'r', encoding='utf-8') as f:
+            text = f.read()
+    except (OSError, UnicodeDecodeError) as e:
+        raise ConfigError(f"{label}: cannot read file: {e}")
+
+    try:
+        config = yaml.safe_load(text)
+    except yaml.YAMLError as e:
+        raise ConfigError(describe_yaml_error(e, text, label))
+
+    if config is None:
+        return {}
+    if not isinstance(config, dict):
+        raise ConfigError(f"{label}: expected a mapping at the top level, got {type(config).__name__}")
+
+    return config
@@ -8,20 +8,39 @@ Handles automatic dependency installation for tools:
 """
 
 import os
+import re
 import sys
+import shlex
 import subprocess
 import venv
 import shutil
+import threading
+import time
+import signal
+import contextlib
 from pathlib import Path
-from typing import List, Dict, Optional, Tuple
+from typing import Callable, List, Dict, Optional, Tuple
 import json
-import yaml
 import logging
 
 from .platform_utils import PlatformUtils
+from .env import env
+from .config_loader import load_yaml_file, ConfigError
+
+try:
+    import psutil
+except ImportError:
+    psutil = None
 
 # Note: Interactive functionality removed - tools should implement their own UI
 
+# Main file extension -> tool type
+TOOL_TYPES = {'.py': 'python', '.sh': 'shell', '.js': 'node', '.rb': 'ruby'}
+
+# Tool types whose main file is run by an interpreter from PATH, with the
+# candidate commands tried in order (type_defaults.<type>.interpreters overrides)
+TOOL_INTERPRETERS = {'node': ['node', 'nodejs'], 'ruby': ['ruby']}
+
 
 class DependencyManager:
     """Manages tool dependencies automatically"""
@@ -60,11 +79,9 @@ class DependencyManager:
             return {}
         
         try:
-            with open(config_file, 'r', encoding='utf-8') as f:
-                config = yaml.safe_load(f)
-            return config or {}
-        except Exception as e:
-            self.logger.debug(f"Failed to load dependencies config: {e}")
+            return load_yaml_file(config_file, 'config/dependencies.yaml')
+        except ConfigError as e:
+            self.logger.warning(f"⚠️  Ignoring dependencies config: {e}")
             return {}
     
     def ensure_tool_dependencies(self, tool_info: Dict) -> Tuple[bool, str]:
@@ -81,6 +98,14 @@ class DependencyManager:
             # Start check message; append status at the end of the same line
             print(f"Checking dependencies for {tool_name}...", end="", flush=True)
             
+            # Nothing else matters if the main file cannot be started at all
+            interpreter_error = self.check_tool_interpreter(tool_info)
+            if interpreter_error:
+                print(" ❌")
+                print()  # empty line after completion
+                print("❌ Interpreter not available")
+                return False, interpreter_error
+            
             # Check Python dependencies
             if tool_info.get('has_python_deps', False):
                 self.logger.info(f"🔍 Checking Python dependencies for {tool_name}")
@@ -144,7 +169,7 @@ class DependencyManager:
                     
                     # Upgrade pip in new environment
                     pip_exe = self._get_pip_executable()
-                    if pip_exe:
+                    if pip_exe and not env.offline:
                         self.logger.info("📦 Upgrading pip in virtual environment...")
                         result = subprocess.run(
                             [str(pip_exe), 'install', '--upgrade', 'pip'],
@@ -165,6 +190,10 @@ class DependencyManager:
                 self.logger.debug(f"Python dependencies already satisfied for {tool_name}")
                 return True, "Dependencies already installed"
             
+            if env.offline:
+                return False, (f"Python requirements for {tool_name} are not installed and "
+                               f"OPSKIT_OFFLINE is set; run the tool once while online")
+            
             # Get pip executable path
             pip_exe = self._get_pip_executable()
             if not pip_exe or not pip_exe.exists():
@@ -400,6 +429,10 @@ class DependencyManager:
         if not commands and not packages:
             result = True
         
+        # An installed but too old dependency counts as missing
+        if result and dep_config.get('min_version'):
+            result = self._is_version_satisfied(dep_name, dep_config)
+        
         # Cache the result
         self._system_deps_cache[dep_name] = result
         self._last_cache_time = current_time
@@ -408,6 +441,51 @@ class DependencyManager:
         
         return result
     
+    @staticmethod
+    def _parse_version(version: str) -> Tuple[int, ...]:
+        """Turn '8.0.36' (or '8.0.36-ubuntu') into (8, 0, 36) for comparison"""
+        match = re.match(r'\d+(?:\.\d+)*', str(version).strip().lstrip('vV'))
+        return tuple(int(part) for part in match.group(0).split('.')) if match else ()
+    
+    def _is_version_satisfied(self, dep_name: str, dep_config: Dict) -> bool:
+        """
+        Check an installed dependency against its min_version
+        
+        The version is read from version_command (default: first command with
+        --version) using version_regex (default: the first dotted number).
+        """
+        min_version = str(dep_config['min_version'])
+        version_command = dep_config.get('version_command')
+        if isinstance(version_command, str):
+            version_command = shlex.split(version_command)
+        if not version_command:
+            commands = dep_config.get('commands') or []
+            if not commands:
+                self.logger.warning(f"⚠️  {dep_name}: min_version set but no version_command or commands to check")
+                return False
+            version_command = [commands[0], '--version']
+        
+        try:
+            result = subprocess.run(version_command, capture_output=True, text=True, timeout=10)
+            output = result.stdout + result.stderr
+        except (OSError, subprocess.TimeoutExpired) as e:
+            self.logger.warning(f"⚠️  {dep_name}: cannot run {' '.join(version_command)}: {e}")
+            return False
+        
+        version_regex = dep_config.get('version_regex') or r'(\d+(?:\.\d+)+)'
+        match = re.search(version_regex, output)
+        if not match:
+            self.logger.warning(f"⚠️  {dep_name}: no version found in output of {' '.join(version_command)}")
+            return False
+        
+        installed_version = match.group(1) if match.groups() else match.group(0)
+        if self._parse_version(installed_version) < self._parse_version(min_version):
+            self.logger.warning(f"⚠️  {dep_name} {installed_version} is older than required {min_version}")
+            return False
+        
+        self.logger.debug(f"✅ {dep_name} {installed_version} satisfies >= {min_version}")
+        return True
+    
     def _install_system_dependencies(self, missing_deps: List[str]) -> Tuple[List[str], List[str]]:
         """Install missing system dependencies"""
         if not missing_deps or not self.dependencies_config:
@@ -416,6 +494,10 @@ class DependencyManager:
         settings = self.dependencies_config.get('settings', {})
         auto_install = settings.get('auto_install', False)
         
+        if auto_install and env.offline:
+            self.logger.info("📋 OPSKIT_OFFLINE is set, skipping system dependency auto-install")
+            auto_install = False
+        
         if not auto_install:
             self.logger.info(f"📋 Auto-install disabled, showing installation guidance for {len(missing_deps)} dependencies")
             self._show_install_guidance(missing_deps)
@@ -423,6 +505,14 @@ class DependencyManager:
         
         self.logger.info(f"🔧 Auto-installing {len(missing_deps)} system dependencies: {missing_deps}")
         
+        # Show what will change before anything is installed
+        print(f"{len(missing_deps)} system dependencies will be installed:")
+        self._show_install_preview(missing_deps)
+        if not self._confirm_install():
+            print("Skipped installing system dependencies.")
+            self._show_install_guidance(missing_deps)
+            return [], missing_deps
+        
         installed, failed = [], []
         
         print(f"Installing {len(missing_deps)} system dependencies...")
@@ -444,6 +534,57 @@ class DependencyManager:
         
         return installed, failed
     
+    @staticmethod
+    def _confirm_install() -> bool:
+        """Ask before installing system packages; without a terminal, install as before"""
+        try:
+            if not sys.stdin.isatty():
+                return True
+        except (AttributeError, ValueError):
+            return True
+        
+        try:
+            answer = input("Install them now? (y/N): ")
+        except (EOFError, KeyboardInterrupt):
+            print()
+            return False
+        return answer.strip().lower() in ('y', 'yes')
+    
+    def _resolve_package_name(self, dep_config: Dict) -> Tuple[Optional[str], str]:
+        """
+        Get a dependency's package name for the current platform
+        
+        Returns:
+            Tuple of (package name or None if unmapped, platform description)
+        """
+        packages = dep_config.get('packages', {})
+        os_type = self.platform_utils.get_os_type()
+        
+        if os_type == 'linux':
+            distro = self.platform_utils.get_linux_distribution()
+            return packages.get(distro), f"{os_type} ({distro})"
+        if os_type == 'darwin':
+            return packages.get('macos'), "macOS"
+        return packages.get(os_type), os_type
+    
+    def _show_install_preview(self, missing_deps: List[str]):
+        """Print the package and exact command each dependency will be installed with"""
+        system_deps = self.dependencies_config.get('system_dependencies', {})
+        manager = self._get_preferred_package_manager()
+        
+        for dep_name in missing_deps:
+            package_name, platform_info = self._resolve_package_name(system_deps.get(dep_name, {}))
+            if not package_name:
+                print(f"  {dep_name}: no package for {platform_info}, install it manually")
+                continue
+            
+            command = self.platform_utils.get_install_command(package_name, manager) if manager else None
+            if not command:
+                print(f"  {dep_name} -> {package_name}: no supported package manager found")
+                continue
+            
+            print(f"  {dep_name} -> {package_name}: {' '.join(command)}")
+    
     def _install_dependency(self, dep_name: str) -> bool:
         """Install a single dependency using enhanced package manager support"""
         self.logger.debug(f"🔧 Attempting to install dependency: {dep_name}")
@@ -455,21 +596,7 @@ class DependencyManager:
             self.logger.warning(f"❌ No configuration found for dependency: {dep_name}")
             return False
         
-        # Get package name for current platform
-        packages = dep_config.get('packages', {})
-        os_type = self.platform_utils.get_os_type()
-        
-        if os_type == 'linux':
-            distro = self.platform_utils.get_linux_distribution()
-            package_name = packages.get(distro)
-            platform_info = f"{os_type} ({distro})"
-        elif os_type == 'darwin':
-            package_name = packages.get('macos')
-            platform_info = "macOS"
-        else:
-            package_name = packages.get(os_type)
-            platform_info = os_type
-        
+        package_name, platform_info = self._resolve_package_name(dep_config)
         if not package_name:
             self.logger.warning(f"❌ No package mapping found for {dep_name} on {platform_info}")
             return False
@@ -602,16 +729,120 @@ class DependencyManager:
         """Get Python executable for tool execution (uses shared venv)"""
         return self._get_python_executable()
     
-    def run_tool_with_dependencies(self, tool_info: Dict, args: List[str] = None) -> int:
+    def resolve_interpreter(self, tool_info: Dict) -> Tuple[List[str], Optional[str]]:
+        """
+        Find the first interpreter on PATH from a tool's candidate list
+        
+        Returns:
+            Tuple of (candidates, path of the first one found or None)
+        """
+        candidates = tool_info.get('interpreters') or TOOL_INTERPRETERS.get(tool_info['type'], [])
+        for candidate in candidates:
+            path = shutil.which(candidate)
+            if path:
+                return candidates, path
+        return candidates, None
+    
+    def check_tool_interpreter(self, tool_info: Dict) -> Optional[str]:
+        """
+        Check that the interpreter needed to start a tool's main file exists
+        
+        This is separate from the tool's declared dependencies, which cover
+        what the tool itself calls once running.
+        
+        Returns:
+            Error message if the tool cannot be started, otherwise None
+        """
+        tool_type = tool_info['type']
+        main_file = Path(tool_info['path']) / tool_info['main_file']
+        
+        # Python tools fall back to OpsKit's own interpreter, which always exists
+        if tool_type == 'python':
+            return None
+        
+        candidates, interpreter_path = self.resolve_interpreter(tool_info)
+        if candidates:
+            if not interpreter_path:
+                return f"{' / '.join(candidates)} not found; install it to run {tool_info['name']}"
+            return None
+        
+        # Shell scripts are executed directly, so check the shebang and exec bit
+        try:
+            with open(main_file, 'r', errors='replace') as f:
+                first_line = f.readline().strip()
+        except OSError as e:
+            return f"Cannot read {main_file}: {e}"
+        
+        if not first_line.startswith('#!'):
+            return f"{tool_info['main_file']} has no #! line; add one such as #!/bin/bash"
+        
+        shebang = first_line[2:].split()
+        if not shebang:
+            return f"{tool_info['main_file']} has an empty #! line"
+        
+        interpreter = shebang[0]
+        if os.path.basename(interpreter) == 'env':
+            # "#!/usr/bin/env [-S] bash" - the interpreter is the first non-option argument
+            interpreter = next((arg for arg in shebang[1:] if not arg.startswith('-')), interpreter)
+        
+        if not shutil.which(interpreter):
+            return f"{interpreter} not found; install it to run {tool_info['name']}"
+        
+        if not os.access(main_file, os.X_OK):
+            return f"{tool_info['main_file']} is not executable; run: chmod +x {main_file}"
+        
+        return None
+    
+    def build_tool_command(self, tool_info: Dict, args: List[str] = None) -> List[str]:
+        """Build the command line used to execute a tool's main file"""
+        tool_name = tool_info['name']
+        main_file = Path(tool_info['path']) / tool_info['main_file']
+        
+        # Arguments configured for every tool of this type come first
+        args = tool_info.get('default_args', []) + (args or [])
+        
+        if tool_info['type'] == 'python':
+            # Use virtual environment Python if available
+            python_exe = self.get_tool_python_executable(tool_name)
+            if python_exe:
+                self.logger.debug(f"🐍 Using virtual environment Python: {python_exe}")
+                return [str(python_exe), str(main_file)] + args
+            
+            # Configured candidates (e.g. [python3, python]) before OpsKit's own interpreter
+            _, python_path = self.resolve_interpreter(tool_info)
+            python_path = python_path or sys.executable
+            self.logger.debug(f"🐍 Using system Python: {python_path}")
+            return [python_path, str(main_file)] + args
+        
+        interpreter_error = self.check_tool_interpreter(tool_info)
+        if interpreter_error:
+            raise RuntimeError(interpreter_error)
+        
+        _, interpreter_path = self.resolve_interpreter(tool_info)
+        if interpreter_path:
+            self.logger.debug(f"▶️  Using interpreter: {interpreter_path}")
+            return [interpreter_path, str(main_file)] + args
+        
+        # Shell script
+        self.logger.debug(f"🐚 Running shell script: {main_file}")
+        return [str(main_file)] + args
+    
+    def run_tool_with_dependencies(self, tool_info: Dict, args: List[str] = None,
+                                   prefix_output: bool = False, stdin_data: Optional[bytes] = None,
+                                   on_start: Optional[Callable[[], None]] = None) -> int:
         """
         Run a tool with proper dependency management
         
+        Args:
+            prefix_output: Prefix every stdout/stderr line with [tool-name]
+            stdin_data: Feed this to the tool's stdin instead of inheriting OpsKit's
+            on_start: Called once the tool process has been started
+        
         Returns:
             Exit code from tool execution
         """
         tool_name = tool_info['name']
         tool_path = Path(tool_info['path'])
-        main_file = tool_path / tool_info['main_file']
         
         if args is None:
             args = []
@@ -630,19 +861,7 @@ class DependencyManager:
                 return 1
             
             # Prepare execution command
-            if tool_info['type'] == 'python':
-                # Use virtual environment Python if available
-                python_exe = self.get_tool_python_executable(tool_name)
-                if python_exe:
-                    cmd = [str(python_exe), str(main_file)] + args
-                    self.logger.debug(f"🐍 Using virtual environment Python: {python_exe}")
-                else:
-                    cmd = [sys.executable, str(main_file)] + args
-                    self.logger.debug(f"🐍 Using system Python: {sys.executable}")
-            else:
-                # Shell script
-                cmd = [str(main_file)] + args
-                self.logger.debug(f"🐚 Running shell script: {main_file}")
+            cmd = self.build_tool_command(tool_info, args)
             
             # Change to tool directory
             original_cwd = os.getcwd()
@@ -653,16 +872,31 @@ class DependencyManager:
                 self.logger.debug(f"📋 Executing command: {' '.join(cmd)}")
                 self.logger.info(f"▶️  Starting {tool_name} execution")
                 
-                # Execute tool directly (inherits stdin/stdout/stderr)
-                # Use subprocess.run with proper stdio inheritance for interactive tools
-                result = subprocess.run(cmd, stdin=sys.stdin, stdout=sys.stdout, stderr=sys.stderr)
+                timeout = tool_info.get('timeout') or None
+                if prefix_output:
+                    returncode = self._run_with_prefixed_output(cmd, f"[{tool_name}] ", timeout, stdin_data,
+                                                                on_start)
+                else:
+                    # Execute tool directly (inherits stdin/stdout/stderr) for interactive tools
+                    stdin = sys.stdin if stdin_data is None else subprocess.PIPE
+                    process = subprocess.Popen(cmd, stdin=stdin, stdout=sys.stdout, stderr=sys.stderr,
+                                               start_new_session=self._use_own_session())
+                    if on_start:
+                        on_start()
+                    self._feed_stdin(process, stdin_data)
+                    returncode = self._wait_tool_process(process, timeout)
+                
+                if returncode is None:
+                    self.logger.error(f"❌ Tool {tool_name} timed out after {timeout}s")
+                    print(f"Error: tool {tool_name} timed out after {timeout}s")
+                    return 124
                 
-                if result.returncode == 0:
+                if returncode == 0:
                     self.logger.info(f"✅ Tool {tool_name} completed successfully")
                 else:
-                    self.logger.warning(f"⚠️  Tool {tool_name} exited with code {result.returncode}")
+                    self.logger.warning(f"⚠️  Tool {tool_name} exited with code {returncode}")
                 
-                return result.returncode
+                return returncode
             
             finally:
                 os.chdir(original_cwd)
@@ -673,6 +907,346 @@ class DependencyManager:
             print(f"Error running tool {tool_name}: {e}")
             return 1
     
+    @staticmethod
+    @contextlib.contextmanager
+    def _forward_signals(processes: List[subprocess.Popen]):
+        """
+        Relay SIGINT/SIGTERM to running tools and everything they started,
+        so OpsKit keeps waiting while they clean up
+        
+        With a terminal, tools stay in OpsKit's process group (they may need
+        the terminal), so a Ctrl-C typed there already reaches them and is
+        not sent twice.
+        """
+        def forward(signum, frame):
+            if signum == signal.SIGINT and sys.stdin.isatty():
+                return
+            for process in processes:
+                if process.poll() is None:
+                    DependencyManager._signal_tool(process, signum)
+        
+        previous = {signum: signal.signal(signum, forward) for signum in (signal.SIGINT, signal.SIGTERM)}
+        try:
+            yield
+        finally:
+            for signum, handler in previous.items():
+                signal.signal(signum, handler)
+    
+    @staticmethod
+    def _use_own_session() -> bool:
+        """
+        Whether to start tools in their own session (and process group)
+        
+        Only without a terminal on stdin: a tool that may prompt must stay in
+        the terminal's foreground process group.
+        """
+        try:
+            return not sys.stdin.isatty()
+        except (AttributeError, ValueError):
+            return True
+    
+    @staticmethod
+    def _has_own_group(process: subprocess.Popen) -> bool:
+        """Check whether a tool leads a process group of its own (see _use_own_session)"""
+        try:
+            return os.getpgid(process.pid) == process.pid != os.getpgrp()
+        except OSError:
+            return False
+    
+    @staticmethod
+    def _tool_descendants(process: subprocess.Popen) -> List:
+        """Processes a tool started, collected while they are still its descendants"""
+        if not psutil:
+            return []
+        try:
+            return psutil.Process(process.pid).children(recursive=True)
+        except psutil.Error:
+            return []
+    
+    @staticmethod
+    def _signal_tool(process: subprocess.Popen, signum: int) -> None:
+        """Send a signal to a tool and the processes it started, so none is left orphaned"""
+        if DependencyManager._has_own_group(process):
+            try:
+                os.killpg(process.pid, signum)
+            except OSError:
+                pass
+            return
+        
+        descendants = DependencyManager._tool_descendants(process)
+        try:
+            process.send_signal(signum)
+        except OSError:
+            pass
+        for child in descendants:
+            try:
+                child.send_signal(signum)
+            except psutil.Error:
+                pass
+    
+    @staticmethod
+    def _exit_code(returncode: int) -> int:
+        """Map a Popen return code to a shell-style exit code (128 + N when killed by signal N)"""
+        return 128 - returncode if returncode < 0 else returncode
+    
+    def _wait_tool_process(self, process: subprocess.Popen, timeout: Optional[int] = None) -> Optional[int]:
+        """
+        Wait for a tool process, stopping it once the timeout has passed
+        
+        Returns:
+            Exit code, or None if the tool timed out
+        """
+        try:
+            with self._forward_signals([process]):
+                return self._exit_code(process.wait(timeout=timeout))
+        except subprocess.TimeoutExpired:
+            pass
+        
+        self._stop_processes([process])
+        return None
+    
+    @staticmethod
+    def _stop_processes(processes: List[subprocess.Popen]) -> None:
+        """Terminate tool processes and their descendants, killing whatever outlives a grace period"""
+        # Tools in their own process group are stopped through the group; for the
+        # others collect descendants first: once a tool exits they are reparented and lost
+        groups = [process.pid for process in processes if DependencyManager._has_own_group(process)]
+        descendants = []
+        for process in processes:
+            if process.pid not in groups:
+                descendants += DependencyManager._tool_descendants(process)
+        
+        # Give the tools a chance to clean up before killing them
+        for process in processes:
+            if process.pid in groups:
+                DependencyManager._signal_tool(process, signal.SIGTERM)
+            elif process.poll() is None:
+                process.terminate()
+        for child in descendants:
+            try:
+                child.terminate()
+            except psutil.Error:
+                pass
+        
+        deadline = time.monotonic() + 5
+        for process in processes:
+            try:
+                process.wait(timeout=max(0, deadline - time.monotonic()))
+            except subprocess.TimeoutExpired:
+                process.kill()
+                process.wait()
+        
+        # Group members outliving their tool get a moment more, then are killed
+        group_deadline = time.monotonic() + 1
+        for pgid in groups:
+            try:
+                while time.monotonic() < group_deadline:
+                    os.killpg(pgid, 0)
+                    time.sleep(0.05)
+                os.killpg(pgid, signal.SIGKILL)
+            except OSError:
+                pass  # the group is empty
+        
+        if descendants:
+            _, alive = psutil.wait_procs(descendants, timeout=1)
+            for child in alive:
+                try:
+                    child.kill()
+                except psutil.Error:
+                    pass
+    
+    @staticmethod
+    def _feed_stdin(process: subprocess.Popen, data: Optional[bytes]) -> None:
+        """Write data to a process's stdin pipe in the background, then close it"""
+        if data is None:
+            return
+        
+        def write():
+            try:
+                process.stdin.write(data)
+                process.stdin.close()
+            except OSError:
+                pass  # the tool exited or closed stdin without reading everything
+        
+        threading.Thread(target=write, daemon=True).start()
+    
+    def _run_with_prefixed_output(self, cmd: List[str], prefix: str, timeout: Optional[int] = None,
+                                  stdin_data: Optional[bytes] = None,
+                                  on_start: Optional[Callable[[], None]] = None) -> Optional[int]:
+        """Run a command, relaying its stdout/stderr line by line with a prefix"""
+        sys.stdout.flush()
+        sys.stderr.flush()
+        
+        stdin = sys.stdin if stdin_data is None else subprocess.PIPE
+        process = subprocess.Popen(cmd, stdin=stdin, stdout=subprocess.PIPE, stderr=subprocess.PIPE,
+                                   start_new_session=self._use_own_session())
+        if on_start:
+            on_start()
+        self._feed_stdin(process, stdin_data)
+        relays = [
+            threading.Thread(target=self._relay_lines, args=(pipe, target.buffer, prefix.encode()), daemon=True)
+            for pipe, target in ((process.stdout, sys.stdout), (process.stderr, sys.stderr))
+        ]
+        for relay in relays:
+            relay.start()
+        
+        returncode = self._wait_tool_process(process, timeout)
+        
+        # Leftover grandchildren may still hold the pipes open after a timeout
+        for relay in relays:
+            relay.join(timeout=None if returncode is not None else 1)
+        
+        return returncode
+    
+    @staticmethod
+    def _relay_lines(source, target, prefix: bytes) -> None:
+        """Copy lines from source to target as they arrive; a trailing partial line is written at EOF"""
+        for line in iter(source.readline, b''):
+            target.write(prefix + line)
+            target.flush()
+        source.close()
+    
+    def run_tool_pipeline(self, stages: List[Tuple[Dict, List[str], Dict[str, str]]],
+                          on_start: Optional[Callable[[Dict], None]] = None) -> Tuple[int, Optional[int]]:
+        """
+        Run tool stages concurrently with stdout of each stage piped to the next stage's stdin
+        
+        Args:
+            stages: (tool_info, args, env) per stage; dependencies must already be satisfied
+            on_start: Called with a stage's tool_info once its process has been started
+        
+        Returns:
+            (exit_code, failed_stage_index) - index is None when every stage succeeded
+        """
+        processes = []
+        previous_stdout = sys.stdin
+        
+        try:
+            for index, (tool_info, args, env) in enumerate(stages):
+                cmd = self.build_tool_command(tool_info, args)
+                is_last = index == len(stages) - 1
+                self.logger.debug(f"📋 Pipeline stage {index + 1}: {' '.join(cmd)}")
+                
+                process = subprocess.Popen(
+                    cmd,
+                    cwd=tool_info['path'],
+                    env=env,
+                    stdin=previous_stdout,
+                    stdout=sys.stdout if is_last else subprocess.PIPE,
+                    stderr=sys.stderr,
+                    start_new_session=self._use_own_session()
+                )
+                
+                # Let the upstream stage receive SIGPIPE if this stage exits early
+                if processes:
+                    processes[-1].stdout.close()
+                
+                processes.append(process)
+                previous_stdout = process.stdout
+                if on_start:
+                    on_start(tool_info)
+        except Exception as e:
+            self.logger.error(f"❌ Failed to start pipeline stage {len(processes) + 1}: {e}")
+            for process in processes:
+                process.kill()
+                process.wait()
+            return 1, len(processes)
+        
+        # Each stage's timeout counts from the start of the pipeline; one stage
+        # running over stops the whole pipeline, as its neighbours would block
+        deadlines = [time.monotonic() + tool_info['timeout'] if tool_info.get('timeout') else None
+                     for tool_info, _, _ in stages]
+        with self._forward_signals(processes):
+            timed_out = self._wait_pipeline(processes, deadlines)
+        
+        if timed_out is not None:
+            self._stop_processes(processes)
+            tool_info = stages[timed_out][0]
+            self.logger.error(f"❌ Pipeline stage {timed_out + 1} ({tool_info['name']}) "
+                              f"timed out after {tool_info['timeout']}s")
+            print(f"Error: tool {tool_info['name']} timed out after {tool_info['timeout']}s", file=sys.stderr)
+            return 124, timed_out
+        
+        return_codes = [self._exit_code(process.returncode) for process in processes]
+        for index, return_code in enumerate(return_codes):
+            if return_code != 0:
+                return return_code, index
+        
+        return 0, None
+    
+    @staticmethod
+    def _wait_pipeline(processes: List[subprocess.Popen], deadlines: List[Optional[float]]) -> Optional[int]:
+        """
+        Wait for every pipeline process to exit, or until one passes its deadline
+        
+        Returns:
+            Index of the first process found past its deadline, or None if all exited
+        """
+        if not any(deadlines):
+            for process in processes:
+                process.wait()
+            return None
+        
+        while True:
+            running = [index for index, process in enumerate(processes) if process.poll() is None]
+            if not running:
+                return None
+            
+            now = time.monotonic()
+            for index in running:
+                if deadlines[index] is not None and now >= deadlines[index]:
+                    return index
+            
+            try:
+                processes[running[0]].wait(timeout=0.1)
+            except subprocess.TimeoutExpired:
+                pass
+    
+    def run_tool_health_check(self, tool_info: Dict, timeout: int = 60,
+                              env: Optional[Dict[str, str]] = None) -> Tuple[bool, str]:
+        """
+        Run a tool's declared health command without installing anything
+        
+        Args:
+            env: Environment for the check (default: OpsKit's own)
+        
+        Returns:
+            (passed, message)
+        """
+        tool_name = tool_info['name']
+        health_args = tool_info.get('health_command')
+        if isinstance(health_args, str):
+            health_args = shlex.split(health_args)
+        
+        missing_deps = self._check_system_dependencies(tool_info)
+        if missing_deps:
+            return False, f"Missing system dependencies: {', '.join(missing_deps)}"
+        
+        try:
+            cmd = self.build_tool_command(tool_info, health_args)
+            self.logger.debug(f"📋 Running health check for {tool_name}: {' '.join(cmd)}")
+            result = subprocess.run(
+                cmd,
+                cwd=tool_info['path'],
+                env=env,
+                stdin=subprocess.DEVNULL,
+                capture_output=True,
+                text=True,
+                timeout=timeout
+            )
+        except subprocess.TimeoutExpired:
+            return False, f"Health check timed out after {timeout} seconds"
+        except Exception as e:
+            return False, f"Health check failed to start: {e}"
+        
+        output = (result.stdout.strip() or result.stderr.strip()).splitlines()
+        message = output[-1] if output else ''
+        
+        if result.returncode != 0:
+            return False, message or f"Exited with code {result.returncode}"
+        
+        return True, message or "OK"
+    
     def clean_tool_cache(self, tool_name: str) -> bool:
         """Clean cache for a specific tool (removes requirement cache)"""
         try:
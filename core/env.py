@@ -13,6 +13,7 @@ Usage:
 
 import os
 from pathlib import Path
+from typing import Optional
 from dotenv import load_dotenv, dotenv_values
 
 
@@ -40,6 +41,10 @@ class EnvConfig:
             cache_dir = str(opskit_root / cache_dir)
         return cache_dir
     
+    @property
+    def data_dir(self) -> str:
+        return str(env_file.parent)
+    
     @property
     def logs_dir(self) -> str:
         logs_dir = os.getenv('OPSKIT_PATHS_LOGS_DIR', 'logs')
@@ -48,6 +53,38 @@ class EnvConfig:
         return logs_dir
     
     
+    @property
+    def log_file(self) -> Optional[str]:
+        """File OpsKit's own log records are also written to (relative to logs_dir)"""
+        log_file = os.getenv('OPSKIT_LOG_FILE')
+        if log_file and not os.path.isabs(log_file):
+            log_file = str(Path(self.logs_dir) / log_file)
+        return log_file or None
+    
+    @property
+    def console_log_level(self) -> Optional[str]:
+        """Console level of OpsKit's own log records: error, warning, info or debug"""
+        level = os.getenv('OPSKIT_LOGGING_CONSOLE_LEVEL', '').lower()
+        return level if level in ('error', 'warning', 'info', 'debug') else None
+    
+    @property
+    def log_format(self) -> str:
+        """Format of OpsKit's own log records: 'text' (default) or 'json'"""
+        return os.getenv('OPSKIT_LOG_FORMAT', 'text').lower()
+    
+    @property
+    def recent_tools(self) -> int:
+        """How many recently run tools interactive mode lists first (0 hides the list)"""
+        try:
+            return max(0, int(os.getenv('OPSKIT_RECENT_TOOLS', '5')))
+        except ValueError:
+            return 5
+    
+    @property
+    def offline(self) -> bool:
+        """OPSKIT_OFFLINE=1: never touch the network (no installs, no git fetch)"""
+        return os.getenv('OPSKIT_OFFLINE', '').lower() in ('1', 'true', 'yes')
+    
     @property
     def version(self) -> str:
         return OPSKIT_VERSION
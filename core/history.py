@@ -0,0 +1,80 @@
+"""
+Tool Run History Module for OpsKit
+
+Persists when each tool was last run, and which tools have been seen,
+in data/history.json.
+
+Usage:
+    from core.history import record_tool_run, get_last_run, get_recent_tools, get_seen_tools, add_seen_tools
+
+    record_tool_run('mysql-sync')
+    print(get_last_run('mysql-sync'))
+    print(get_recent_tools(5))
+    add_seen_tools(['mysql-sync'])
+"""
+
+import json
+import time
+from pathlib import Path
+from typing import Dict, List, Optional
+
+from .env import env
+
+
+def _history_file() -> Path:
+    return Path(env.data_dir) / 'history.json'
+
+
+def load_history() -> Dict:
+    """Load the history file, returning an empty history if missing or unreadable"""
+    try:
+        with open(_history_file(), 'r', encoding='utf-8') as f:
+            history = json.load(f)
+        if isinstance(history, dict):
+            history.setdefault('last_run', {})
+            return history
+    except (OSError, ValueError):
+        pass
+    
+    return {'last_run': {}}
+
+
+def save_history(history: Dict) -> None:
+    """Write the history file (best effort - history must never break a run)"""
+    try:
+        history_file = _history_file()
+        history_file.parent.mkdir(parents=True, exist_ok=True)
+        with open(history_file, 'w', encoding='utf-8') as f:
+            json.dump(history, f, indent=2)
+    except OSError:
+        pass
+
+
+def record_tool_run(tool_name: str) -> None:
+    """Record that a tool was started now"""
+    history = load_history()
+    history['last_run'][tool_name] = time.time()
+    save_history(history)
+
+
+def get_last_run(tool_name: str) -> Optional[float]:
+    """Get the timestamp of a tool's last run, or None if never run"""
+    return load_history()['last_run'].get(tool_name)
+
+
+def get_recent_tools(limit: int) -> List[str]:
+    """Get the names of the most recently run tools, newest first"""
+    last_run = load_history()['last_run']
+    return sorted(last_run, key=last_run.get, reverse=True)[:limit]
+
+
+def get_seen_tools() -> Optional[List[str]]:
+    """Get the names of tools already reported, or None if none were recorded yet"""
+    return load_history().get('seen_tools')
+
+
+def add_seen_tools(tool_names: List[str]) -> None:
+    """Record tools as reported, so later checks no longer count them as new"""
+    history = load_history()
+    history['seen_tools'] = sorted(set(tool_names) | set(history.get('seen_tools') or []))
+    save_history(history)
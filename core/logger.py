@@ -0,0 +1,144 @@
+"""
+Logging Module for OpsKit
+
+Helpers for OpsKit's own log output (tools implement their own logging).
+
+Usage:
+    from core.logger import (warning_counter, install_console_handler, enable_debug_logging,
+                             enable_log_file, set_console_level)
+
+    install_console_handler()
+    enable_log_file('logs/opskit.log')
+    set_console_level(logging.ERROR)
+    enable_debug_logging()
+    warning_counter.install()
+    ...
+    if warning_counter.count:
+        sys.exit(1)
+"""
+
+import json
+import logging
+from datetime import datetime, timezone
+from pathlib import Path
+
+from .env import env
+
+
+class JsonFormatter(logging.Formatter):
+    """Format each record as a single-line JSON object (OPSKIT_LOG_FORMAT=json)"""
+
+    def format(self, record: logging.LogRecord) -> str:
+        entry = {
+            'ts': datetime.fromtimestamp(record.created, timezone.utc).isoformat(),
+            'level': record.levelname.lower(),
+            'logger': record.name,
+            'msg': record.getMessage(),
+        }
+        if record.exc_info:
+            entry['exc'] = self.formatException(record.exc_info)
+        return json.dumps(entry, ensure_ascii=False)
+
+
+def _formatter(text_format: str) -> logging.Formatter:
+    """Formatter for the configured log format, using text_format for text logs"""
+    if env.log_format == 'json':
+        return JsonFormatter()
+    return logging.Formatter(text_format)
+
+
+# Console handler for OpsKit log records, created on first use
+_console_handler = None
+
+
+def _ensure_console_handler() -> logging.Handler:
+    """Attach the console handler to the root logger (WARNING and up by default)"""
+    global _console_handler
+    root = logging.getLogger()
+
+    if _console_handler is None:
+        _console_handler = logging.StreamHandler()
+        _console_handler.setLevel(logging.WARNING)
+        _console_handler.setFormatter(_formatter('%(message)s'))
+
+    if _console_handler not in root.handlers:
+        root.addHandler(_console_handler)
+
+    return _console_handler
+
+
+def install_console_handler() -> None:
+    """
+    Print OpsKit's log records (WARNING and up) on the console in the configured format
+    
+    Without it, records go through logging's last-resort handler as plain text,
+    even with OPSKIT_LOG_FORMAT=json.
+    """
+    _ensure_console_handler()
+
+
+def enable_log_file(path: str) -> None:
+    """
+    Also write OpsKit's log records, including INFO, to a file with timestamps
+    
+    The console keeps showing only what it showed before.
+    """
+    root = logging.getLogger()
+    log_path = Path(path)
+    log_path.parent.mkdir(parents=True, exist_ok=True)
+    
+    file_handler = logging.FileHandler(log_path, encoding='utf-8')
+    file_handler.setFormatter(_formatter('%(asctime)s [%(levelname)s] %(name)s: %(message)s'))
+    
+    # A file handler replaces logging's last-resort console output, so add ours
+    _ensure_console_handler()
+    root.addHandler(file_handler)
+    if root.getEffectiveLevel() > logging.INFO:
+        root.setLevel(logging.INFO)
+
+
+def set_console_level(level: int) -> None:
+    """Show OpsKit's log records from level up on the console (default WARNING)"""
+    console = _ensure_console_handler()
+    console.setLevel(level)
+    
+    # Below WARNING, say which level and module each line comes from
+    text_format = '[%(levelname)s] %(name)s: %(message)s' if level < logging.WARNING else '%(message)s'
+    console.setFormatter(_formatter(text_format))
+    
+    root = logging.getLogger()
+    if root.getEffectiveLevel() > level:
+        root.setLevel(level)
+
+
+def enable_debug_logging() -> None:
+    """Show OpsKit's debug log records on the console for this invocation"""
+    set_console_level(logging.DEBUG)
+
+
+class WarningCounter(logging.Handler):
+    """Count WARNING and higher records emitted by OpsKit core modules"""
+
+    def __init__(self):
+        super().__init__(level=logging.WARNING)
+        self.count = 0
+
+    def emit(self, record: logging.LogRecord) -> None:
+        self.count += 1
+
+    def install(self) -> None:
+        """Attach the counter to the root logger"""
+        root = logging.getLogger()
+        if self in root.handlers:
+            return
+
+        # Attaching any handler disables logging's last-resort stderr output,
+        # so keep warnings visible the same way they were printed before
+        if not root.handlers:
+            _ensure_console_handler()
+
+        root.addHandler(self)
+
+
+# Global warning counter used by --strict
+warning_counter = WarningCounter()
@@ -333,6 +333,8 @@ class PlatformUtils:
                 return 'pacman'
             elif distro_name in ['opensuse', 'sle'] and 'zypper' in available:
                 return 'zypper'
+            elif distro_name == 'alpine' and 'apk' in available:
+                return 'apk'
             
             # Fallback to first available
             return available[0]
@@ -465,6 +467,27 @@ class PlatformUtils:
         
         return packages
 
+    @classmethod
+    def get_install_command(cls, package_name: str, package_manager: str) -> Optional[List[str]]:
+        """
+        Build the command that installs a package with the given package manager
+        
+        Returns:
+            Command as a list of arguments, or None if the manager is unsupported
+        """
+        managers = cls.PACKAGE_MANAGERS.get(cls.get_os_type(), {})
+        manager_config = managers.get(package_manager)
+        if not manager_config:
+            return None
+        
+        command_parts = manager_config['install'].format(package_name).split()
+        
+        # Root (e.g. Alpine containers) usually has no sudo and doesn't need it
+        if command_parts[0] == 'sudo' and (os.geteuid() == 0 or not cls.command_exists('sudo')):
+            command_parts = command_parts[1:]
+        
+        return command_parts
+    
     @classmethod
     def install_system_package(cls, package_name: str, 
                              package_manager: Optional[str] = None, 
@@ -490,16 +513,10 @@ class PlatformUtils:
         if not force_install and cls.is_package_installed(package_name, package_manager):
             return (True, f"Package {package_name} is already installed")
         
-        os_type = cls.get_os_type()
-        managers = cls.PACKAGE_MANAGERS.get(os_type, {})
-        manager_config = managers.get(package_manager)
-        
-        if not manager_config:
+        command_parts = cls.get_install_command(package_name, package_manager)
+        if not command_parts:
             return (False, f"Unsupported package manager: {package_manager}")
         
-        install_command = manager_config['install'].format(package_name)
-        command_parts = install_command.split()
-        
         print(f"Installing {package_name} using {package_manager}...")
         success, stdout, stderr = cls.run_command(command_parts, timeout=300)
         
@@ -584,6 +601,24 @@ exec "{sys.executable}" "{script_path}" "$@"
         except Exception:
             return False
     
+    @classmethod
+    def open_url(cls, url: str) -> bool:
+        """
+        Open a URL or file:// URI in the default browser
+        
+        Returns:
+            True if a browser was launched, False if none is available (headless)
+        """
+        # Without a display, Linux browsers either fail or take over the terminal
+        if cls.get_os_type() == 'linux' and not (os.environ.get('DISPLAY') or os.environ.get('WAYLAND_DISPLAY')):
+            return False
+        
+        try:
+            import webbrowser
+            return webbrowser.open(url)
+        except Exception:
+            return False
+    
     @classmethod
     def get_shell_rc_files(cls) -> List[str]:
         """Get list of shell RC files that might need PATH updates"""
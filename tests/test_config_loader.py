@@ -0,0 +1,60 @@
+"""
+Tests for YAML config loading and parse error messages
+
+Run from the OpsKit root:
+    python3 -m unittest discover tests
+"""
+
+import tempfile
+import unittest
+from pathlib import Path
+
+from core.config_loader import load_yaml_file, ConfigError
+
+
+class LoadYamlFileTest(unittest.TestCase):
+
+    def setUp(self):
+        temp_dir = tempfile.TemporaryDirectory()
+        self.addCleanup(temp_dir.cleanup)
+        self.path = Path(temp_dir.name) / 'tools.yaml'
+
+    def load(self, text: str):
+        self.path.write_text(text, encoding='utf-8')
+        return load_yaml_file(self.path, 'config/tools.yaml')
+
+    def test_valid_mapping(self):
+        self.assertEqual(self.load('tools:\n  system: {}\n'), {'tools': {'system': {}}})
+
+    def test_empty_file(self):
+        self.assertEqual(self.load(''), {})
+
+    def test_malformed_reports_line_and_column(self):
+        with self.assertRaises(ConfigError) as raised:
+            self.load('tools:\n  database:\n    mysql-sync: {version: "1.0"\n  network: {}\n')
+
+        lines = str(raised.exception).splitlines()
+        self.assertTrue(lines[0].startswith('config/tools.yaml:4:3: '), lines[0])
+        # The construct that failed starts at the unclosed '{' on line 3
+        self.assertEqual(lines[1:], [
+            '    3 |     mysql-sync: {version: "1.0"',
+            '      |                 ^',
+            '    4 |   network: {}',
+            '      |   ^',
+        ])
+
+    def test_missing_colon(self):
+        with self.assertRaises(ConfigError) as raised:
+            self.load('tools:\n  system:\n    disk-usage\n    version: "1.0"\n')
+        self.assertRegex(str(raised.exception), r'^config/tools\.yaml:\d+:\d+: ')
+        self.assertIn('|', str(raised.exception))
+        self.assertIn('^', str(raised.exception))
+
+    def test_not_a_mapping(self):
+        with self.assertRaises(ConfigError) as raised:
+            self.load('- a\n- b\n')
+        self.assertEqual(str(raised.exception), 'config/tools.yaml: expected a mapping at the top level, got list')
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,94 @@
+"""
+Tests for tool cooldowns: duration parsing, run history and the remaining wait
+
+Run from the OpsKit root:
+    python3 -m unittest discover tests
+"""
+
+import tempfile
+import time
+import unittest
+from pathlib import Path
+from unittest import mock
+
+from core import history
+from core.cli import OpsKitCLI
+
+
+class ParseDurationTest(unittest.TestCase):
+
+    def test_numbers_are_seconds(self):
+        self.assertEqual(OpsKitCLI._parse_duration(90), 90)
+        self.assertEqual(OpsKitCLI._parse_duration(1.5), 1)
+        self.assertEqual(OpsKitCLI._parse_duration('45'), 45)
+
+    def test_unit_suffixes(self):
+        self.assertEqual(OpsKitCLI._parse_duration('30s'), 30)
+        self.assertEqual(OpsKitCLI._parse_duration('5m'), 300)
+        self.assertEqual(OpsKitCLI._parse_duration('1h'), 3600)
+        self.assertEqual(OpsKitCLI._parse_duration('1d'), 86400)
+        self.assertEqual(OpsKitCLI._parse_duration(' 2H '), 7200)
+
+    def test_empty_means_no_duration(self):
+        self.assertEqual(OpsKitCLI._parse_duration(''), 0)
+        self.assertEqual(OpsKitCLI._parse_duration(0), 0)
+
+    def test_invalid_is_zero(self):
+        self.assertEqual(OpsKitCLI._parse_duration('soon'), 0)
+        self.assertEqual(OpsKitCLI._parse_duration('10min'), 0)
+
+    def test_invalid_is_reported(self):
+        # _load_tools_config warns about durations this rejects
+        for value in ('soon', '10min', '-5', True):
+            self.assertFalse(OpsKitCLI._is_duration(value), value)
+        for value in (0, 30, '30s', '5m', '1.5h', '1d'):
+            self.assertTrue(OpsKitCLI._is_duration(value), value)
+
+
+class HistoryTest(unittest.TestCase):
+
+    def setUp(self):
+        temp_dir = tempfile.TemporaryDirectory()
+        self.addCleanup(temp_dir.cleanup)
+        history_file = Path(temp_dir.name) / 'history.json'
+        patcher = mock.patch.object(history, '_history_file', return_value=history_file)
+        patcher.start()
+        self.addCleanup(patcher.stop)
+
+    def test_never_run(self):
+        self.assertIsNone(history.get_last_run('mysql-sync'))
+
+    def test_record_then_get(self):
+        before = time.time()
+        history.record_tool_run('mysql-sync')
+        last_run = history.get_last_run('mysql-sync')
+        self.assertIsNotNone(last_run)
+        self.assertGreaterEqual(last_run, before)
+        self.assertIsNone(history.get_last_run('s3-sync'))
+
+
+class CooldownRemainingTest(unittest.TestCase):
+
+    tool = {'name': 'mysql-sync', 'cooldown': 3600}
+
+    def remaining(self, last_run, tool=None):
+        with mock.patch('core.cli.get_last_run', return_value=last_run):
+            return OpsKitCLI._cooldown_remaining(tool or self.tool)
+
+    def test_cooldown_active(self):
+        remaining = self.remaining(time.time() - 60)
+        self.assertGreater(remaining, 3500)
+        self.assertLessEqual(remaining, 3540)
+
+    def test_cooldown_elapsed(self):
+        self.assertEqual(self.remaining(time.time() - 3601), 0)
+
+    def test_never_run(self):
+        self.assertEqual(self.remaining(None), 0)
+
+    def test_no_cooldown(self):
+        self.assertEqual(self.remaining(time.time(), {'name': 'disk-usage', 'cooldown': 0}), 0)
+
+
+if __name__ == '__main__':
+    unittest.main()
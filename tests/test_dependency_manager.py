@@ -0,0 +1,220 @@
+"""
+Tests for the dependency manager: interpreter lookup and checks, minimum
+versions and confirming system installs
+
+Run from the OpsKit root:
+    python3 -m unittest discover tests
+"""
+
+import os
+import subprocess
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+from core.dependency_manager import DependencyManager
+
+
+def which_only(*found):
+    """shutil.which replacement that finds only the given commands"""
+    return lambda command: f"/usr/bin/{os.path.basename(command)}" if os.path.basename(command) in found else None
+
+
+class DependencyManagerTestCase(unittest.TestCase):
+
+    def setUp(self):
+        temp_dir = tempfile.TemporaryDirectory()
+        self.addCleanup(temp_dir.cleanup)
+        self.root = Path(temp_dir.name)
+        self.manager = DependencyManager(self.root)
+
+    def tool(self, tool_type: str, main_file: str, content: str = '', executable: bool = True, **fields):
+        """Write a tool main file and return its tool_info"""
+        tool_dir = self.root / 'tools' / 'test' / 'demo'
+        tool_dir.mkdir(parents=True, exist_ok=True)
+        main_path = tool_dir / main_file
+        main_path.write_text(content)
+        main_path.chmod(0o755 if executable else 0o644)
+        return {'name': 'demo', 'type': tool_type, 'path': str(tool_dir), 'main_file': main_file, **fields}
+
+
+class CheckToolInterpreterTest(DependencyManagerTestCase):
+
+    def check(self, tool_info, *found):
+        with mock.patch('core.dependency_manager.shutil.which', side_effect=which_only(*found)):
+            return self.manager.check_tool_interpreter(tool_info)
+
+    def test_missing_node(self):
+        error = self.check(self.tool('node', 'main.js', 'console.log(1)\n'))
+        self.assertEqual(error, "node / nodejs not found; install it to run demo")
+
+    def test_missing_ruby(self):
+        error = self.check(self.tool('ruby', 'main.rb', 'puts 1\n'))
+        self.assertEqual(error, "ruby not found; install it to run demo")
+
+    def test_node_found(self):
+        self.assertIsNone(self.check(self.tool('node', 'main.js', 'console.log(1)\n'), 'node'))
+
+    def test_missing_shebang_interpreter(self):
+        error = self.check(self.tool('shell', 'main.sh', '#!/usr/bin/fish\necho hi\n'), 'bash')
+        self.assertEqual(error, "/usr/bin/fish not found; install it to run demo")
+
+    def test_env_shebang(self):
+        tool_info = self.tool('shell', 'main.sh', '#!/usr/bin/env bash\necho hi\n')
+        self.assertIsNone(self.check(tool_info, 'bash'))
+        self.assertEqual(self.check(tool_info), "bash not found; install it to run demo")
+
+    def test_env_split_shebang(self):
+        # "env -S" is an option, not the interpreter
+        tool_info = self.tool('shell', 'main.sh', '#!/usr/bin/env -S bash -e\necho hi\n')
+        self.assertIsNone(self.check(tool_info, 'bash'))
+        self.assertEqual(self.check(tool_info, 'env'), "bash not found; install it to run demo")
+
+    def test_no_shebang(self):
+        error = self.check(self.tool('shell', 'main.sh', 'echo hi\n'), 'bash')
+        self.assertEqual(error, "main.sh has no #! line; add one such as #!/bin/bash")
+
+    def test_not_executable(self):
+        tool_info = self.tool('shell', 'main.sh', '#!/bin/bash\necho hi\n', executable=False)
+        error = self.check(tool_info, 'bash')
+        self.assertTrue(error.startswith("main.sh is not executable; run: chmod +x "), error)
+
+    def test_python_needs_no_check(self):
+        self.assertIsNone(self.check(self.tool('python', 'main.py', 'print(1)\n')))
+
+
+class ResolveInterpreterTest(DependencyManagerTestCase):
+
+    def resolve(self, tool_info, *found):
+        with mock.patch('core.dependency_manager.shutil.which', side_effect=which_only(*found)) as which:
+            candidates, path = self.manager.resolve_interpreter(tool_info)
+        return candidates, path, [call[0][0] for call in which.call_args_list]
+
+    def test_first_available_candidate(self):
+        candidates, path, looked_up = self.resolve(self.tool('node', 'main.js'), 'nodejs')
+        self.assertEqual(candidates, ['node', 'nodejs'])
+        self.assertEqual(path, '/usr/bin/nodejs')
+        self.assertEqual(looked_up, ['node', 'nodejs'])
+
+    def test_first_candidate_wins(self):
+        _, path, looked_up = self.resolve(self.tool('node', 'main.js'), 'node', 'nodejs')
+        self.assertEqual(path, '/usr/bin/node')
+        self.assertEqual(looked_up, ['node'])
+
+    def test_none_available(self):
+        tool_info = self.tool('node', 'main.js')
+        _, path, _ = self.resolve(tool_info)
+        self.assertIsNone(path)
+        with mock.patch('core.dependency_manager.shutil.which', return_value=None):
+            error = self.manager.check_tool_interpreter(tool_info)
+        self.assertIn('node', error)
+        self.assertIn('nodejs', error)
+
+    def test_type_defaults_override(self):
+        # type_defaults.node.interpreters replaces TOOL_INTERPRETERS['node']
+        tool_info = self.tool('node', 'main.js', interpreters=['node20', 'node18'])
+        candidates, path, looked_up = self.resolve(tool_info, 'node', 'node18')
+        self.assertEqual(candidates, ['node20', 'node18'])
+        self.assertEqual(path, '/usr/bin/node18')
+        self.assertNotIn('node', looked_up)
+
+    def test_shell_has_no_candidates(self):
+        candidates, path, _ = self.resolve(self.tool('shell', 'main.sh'), 'bash')
+        self.assertEqual((candidates, path), ([], None))
+
+
+class VersionCheckTest(DependencyManagerTestCase):
+
+    def satisfied(self, output: str, dep_config: dict):
+        result = subprocess.CompletedProcess([], 0, stdout=output, stderr='')
+        with mock.patch('core.dependency_manager.subprocess.run', return_value=result) as run:
+            satisfied = self.manager._is_version_satisfied('dep', dep_config)
+        return satisfied, run.call_args[0][0]
+
+    def test_parse_version(self):
+        self.assertEqual(DependencyManager._parse_version('8.0.36'), (8, 0, 36))
+        self.assertEqual(DependencyManager._parse_version('v1.28.2'), (1, 28, 2))
+        self.assertEqual(DependencyManager._parse_version('8.0.36-0ubuntu0.22.04.1'), (8, 0, 36))
+        self.assertEqual(DependencyManager._parse_version('latest'), ())
+
+    def test_mysql_output(self):
+        satisfied, command = self.satisfied(
+            'mysql  Ver 8.0.36 for Linux on x86_64 (MySQL Community Server - GPL)\n',
+            {'commands': ['mysql'], 'min_version': '8.0'})
+        self.assertTrue(satisfied)
+        self.assertEqual(command, ['mysql', '--version'])
+
+    def test_kubectl_output(self):
+        satisfied, command = self.satisfied(
+            'Client Version: v1.28.2\nKustomize Version: v5.0.4-0.20230601165947-6ce0bf390ce3\n',
+            {'commands': ['kubectl'], 'min_version': '1.25', 'version_command': 'kubectl version --client'})
+        self.assertTrue(satisfied)
+        self.assertEqual(command, ['kubectl', 'version', '--client'])
+
+    def test_git_output(self):
+        satisfied, _ = self.satisfied('git version 2.39.5\n', {'commands': ['git'], 'min_version': '2.30'})
+        self.assertTrue(satisfied)
+
+    def test_older_than_min_version(self):
+        with self.assertLogs('core.dependency_manager', level='WARNING'):
+            satisfied, _ = self.satisfied('git version 2.39.5\n', {'commands': ['git'], 'min_version': '2.40'})
+        self.assertFalse(satisfied)
+
+    def test_numeric_comparison(self):
+        # 1.10 is newer than 1.9, which a string comparison would get wrong
+        satisfied, _ = self.satisfied('tool 1.10.0\n', {'commands': ['tool'], 'min_version': '1.9'})
+        self.assertTrue(satisfied)
+
+    def test_no_version_in_output(self):
+        with self.assertLogs('core.dependency_manager', level='WARNING'):
+            satisfied, _ = self.satisfied('unknown option --version\n', {'commands': ['tool'], 'min_version': '1.0'})
+        self.assertFalse(satisfied)
+
+    def test_version_regex(self):
+        satisfied, _ = self.satisfied('build 7 (release 3.2)\n',
+                                      {'commands': ['tool'], 'min_version': '3.1', 'version_regex': r'release (\d+\.\d+)'})
+        self.assertTrue(satisfied)
+
+
+class InstallConfirmationTest(DependencyManagerTestCase):
+
+    def setUp(self):
+        super().setUp()
+        self.manager.dependencies_config = {
+            'settings': {'auto_install': True},
+            'system_dependencies': {'nmap': {'commands': ['nmap'], 'packages': {'ubuntu': 'nmap'}}},
+        }
+        for name, value in (('_show_install_preview', None), ('_show_install_guidance', None),
+                            ('_install_dependency', True)):
+            patcher = mock.patch.object(self.manager, name, return_value=value)
+            setattr(self, name.lstrip('_'), patcher.start())
+            self.addCleanup(patcher.stop)
+
+    def install(self, tty: bool, answer: str = ''):
+        with mock.patch('sys.stdin') as stdin, mock.patch('builtins.input', return_value=answer) as prompt, \
+                mock.patch('builtins.print'):
+            stdin.isatty.return_value = tty
+            result = self.manager._install_system_dependencies(['nmap'])
+        return result, prompt
+
+    def test_declined_on_terminal(self):
+        (installed, failed), prompt = self.install(tty=True, answer='n')
+        self.assertEqual((installed, failed), ([], ['nmap']))
+        prompt.assert_called_once()
+        self.show_install_preview.assert_called_once_with(['nmap'])
+        self.install_dependency.assert_not_called()
+
+    def test_confirmed_on_terminal(self):
+        (installed, failed), _ = self.install(tty=True, answer='y')
+        self.assertEqual((installed, failed), (['nmap'], []))
+
+    def test_no_terminal_installs_without_asking(self):
+        (installed, failed), prompt = self.install(tty=False)
+        self.assertEqual((installed, failed), (['nmap'], []))
+        prompt.assert_not_called()
+        self.show_install_preview.assert_called_once_with(['nmap'])
+
+
+if __name__ == '__main__':
+    unittest.main()